@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/benweidig/tortuga/repo"
+)
+
+// Status is the cached status of all repositories of a base path
+type Status struct {
+	Updated      time.Time    `json:"updated"`
+	Path         string       `json:"path"`
+	Repositories []Repository `json:"repositories"`
+}
+
+// Repository is the cached status of a single repository
+type Repository struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	Branch      string `json:"branch"`
+	Incoming    int    `json:"incoming"`
+	Outgoing    int    `json:"outgoing"`
+	Changes     int    `json:"changes"`
+	Unversioned int    `json:"unversioned"`
+	Error       string `json:"error,omitempty"`
+}
+
+// NewStatus creates a Status of the already updated repositories
+func NewStatus(basePath string, repos []*repo.Repository) *Status {
+	s := &Status{
+		Updated:      time.Now(),
+		Path:         basePath,
+		Repositories: make([]Repository, len(repos)),
+	}
+
+	for idx, r := range repos {
+		cached := Repository{
+			Name:        r.Name,
+			Path:        r.Path(),
+			Branch:      r.Branch,
+			Incoming:    r.Incoming,
+			Outgoing:    r.Outgoing,
+			Changes:     r.Changes,
+			Unversioned: r.Unversioned,
+		}
+		if r.State == repo.StateError {
+			cached.Error = r.Error.Error()
+		}
+		s.Repositories[idx] = cached
+	}
+
+	return s
+}
+
+// DefaultStatusPath returns the path of the status cache in the user's cache directory,
+// e.g. $XDG_CACHE_HOME/tortuga/status.json
+func DefaultStatusPath() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "tortuga", "status.json"), nil
+}
+
+// WriteStatus writes the Status to the file. The file is replaced atomically,
+// so readers never see a partially written cache.
+func WriteStatus(filePath string, s *Status) error {
+	err := os.MkdirAll(filepath.Dir(filePath), 0o755)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), ".status-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filePath)
+}
+
+// ReadStatus reads the Status from the file
+func ReadStatus(filePath string) (*Status, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Status
+	err = json.Unmarshal(data, &s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/benweidig/tortuga/repo"
+	"github.com/benweidig/tortuga/ui"
+
+	"github.com/spf13/cobra"
+)
+
+// Arguments of the checkout/branch commands
+var (
+	autostashArg bool
+	createArg    bool
+	fromArg      string
+)
+
+var checkoutCmd = &cobra.Command{
+	Use:   "checkout <branch> [<path>]",
+	Short: "Switch all repositories to a branch",
+	Long:  "Switches all repositories to a branch. Repositories without the branch are reported, or can get it created from their default branch",
+	Args:  cobra.RangeArgs(1, 2),
+	Run:   runCheckoutCommand,
+}
+
+var branchCmd = &cobra.Command{
+	Use:   "branch <name> [<path>]",
+	Short: "Create a branch in all repositories",
+	Long:  "Creates a branch in all repositories and switches to it",
+	Args:  cobra.RangeArgs(1, 2),
+	Run:   runBranchCommand,
+}
+
+func init() {
+	checkoutCmd.Flags().BoolVar(&autostashArg, "autostash", false, "Stash local changes before switching and reapply them afterwards")
+	checkoutCmd.Flags().BoolVarP(&createArg, "create", "c", false, "Create the branch from the default branch if it doesn't exist")
+	RootCmd.AddCommand(checkoutCmd)
+
+	branchCmd.Flags().BoolVar(&autostashArg, "autostash", false, "Stash local changes before switching and reapply them afterwards")
+	branchCmd.Flags().StringVar(&fromArg, "from", "", "Start point of the new branch (default: HEAD)")
+	RootCmd.AddCommand(branchCmd)
+}
+
+func runCheckoutCommand(_ *cobra.Command, args []string) {
+	branch := args[0]
+	repos := loadRepositories(args[1:])
+
+	checkoutRepositories(repos, func(r *repo.Repository) *repo.CheckoutResult {
+		return r.Checkout(branch, createArg, autostashArg)
+	})
+}
+
+func runBranchCommand(_ *cobra.Command, args []string) {
+	branch := args[0]
+	repos := loadRepositories(args[1:])
+
+	checkoutRepositories(repos, func(r *repo.Repository) *repo.CheckoutResult {
+		return r.CreateBranch(branch, fromArg, autostashArg)
+	})
+}
+
+func checkoutRepositories(repos []*repo.Repository, fn func(r *repo.Repository) *repo.CheckoutResult) {
+	fmt.Println()
+
+	w := ui.NewStdoutWriter()
+
+	results := make([]*repo.CheckoutResult, len(repos))
+
+	w.Render(func() {
+		ui.WriteCheckoutStatus(w, repos, results)
+	})
+
+	forEachRepository(repos, func(idx int, r *repo.Repository) {
+		result := fn(r)
+
+		w.Render(func() {
+			results[idx] = result
+			r.ApplyCheckout(result)
+			ui.WriteCheckoutStatus(w, repos, results)
+		})
+	})
+
+	w.Done()
+
+	for _, result := range results {
+		if result.State == repo.CheckoutStateError {
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/benweidig/tortuga/cache"
+	"github.com/benweidig/tortuga/repo"
+	"github.com/benweidig/tortuga/ui"
+
+	"github.com/spf13/cobra"
+)
+
+// Arguments of the daemon/prompt commands
+var (
+	daemonIntervalArg time.Duration
+	cacheArg          string
+	formatArg         string
+)
+
+// minDaemonInterval is the shortest interval between fetches of the daemon
+const minDaemonInterval = time.Second
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon [<path>]",
+	Short: "Periodically fetch all repositories and write a status cache",
+	Long:  "Runs in the foreground, periodically fetches all repositories, and writes their status to a cache file for cheap consumption by 'tt prompt'",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   runDaemonCommand,
+}
+
+var promptCmd = &cobra.Command{
+	Use:   "prompt",
+	Short: "Print a compact summary of the status cache",
+	Long:  "Prints a compact summary of the status cache written by 'tt daemon', without any git or network access. Prints nothing if there's no cache.",
+	Args:  cobra.NoArgs,
+	Run:   runPromptCommand,
+}
+
+func init() {
+	daemonCmd.Flags().DurationVar(&daemonIntervalArg, "interval", 5*time.Minute, "Interval between fetches")
+	daemonCmd.Flags().StringVar(&cacheArg, "cache", "", "Status cache file (default: $XDG_CACHE_HOME/tortuga/status.json)")
+	RootCmd.AddCommand(daemonCmd)
+
+	promptCmd.Flags().StringVar(&cacheArg, "cache", "", "Status cache file (default: $XDG_CACHE_HOME/tortuga/status.json)")
+	promptCmd.Flags().StringVar(&formatArg, "format", "", "Format with placeholders {repos}, {behind}, {ahead}, {incoming}, {outgoing}, {dirty}, {errors}, {age} (default: \"{behind}↓ {ahead}↑\" with the symbols of the theme)")
+	RootCmd.AddCommand(promptCmd)
+}
+
+// resolveCachePath returns the requested status cache file or the default one
+func resolveCachePath() string {
+	if len(cacheArg) > 0 {
+		return cacheArg
+	}
+
+	cachePath, err := cache.DefaultStatusPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Couldn't determinate cache directory: '%s'.\n", err)
+		os.Exit(1)
+	}
+	return cachePath
+}
+
+func runDaemonCommand(_ *cobra.Command, args []string) {
+	checkInterval(daemonIntervalArg, minDaemonInterval)
+
+	basePath := resolveBasePath(args)
+	cachePath := resolveCachePath()
+
+	ticker := time.NewTicker(daemonIntervalArg)
+	defer ticker.Stop()
+
+	for {
+		// Repositories are searched every time, so new ones are picked up
+		// and no state is carried over
+		repos, _ := findRepositories(basePath)
+		repos, err := filterRepositories(repos, filterArg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid filter: '%s'.\n", err)
+			os.Exit(1)
+		}
+
+		forEachRepository(repos, func(_ int, r *repo.Repository) {
+			r.Update()
+		})
+
+		err = cache.WriteStatus(cachePath, cache.NewStatus(basePath, repos))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Couldn't write status cache: '%s'.\n", err)
+		}
+
+		<-ticker.C
+	}
+}
+
+func runPromptCommand(_ *cobra.Command, _ []string) {
+	status, err := cache.ReadStatus(resolveCachePath())
+	if err != nil {
+		// A prompt shouldn't be cluttered with errors
+		return
+	}
+
+	format := formatArg
+	if len(format) == 0 {
+		format = ui.DefaultPromptFormat()
+	}
+
+	fmt.Println(ui.FormatPrompt(format, status))
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/benweidig/tortuga/repo"
+	"github.com/benweidig/tortuga/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec [<path>] -- <command> [<args>...]",
+	Short: "Run an arbitrary command in all repositories",
+	Long:  "Runs an arbitrary command in the working tree of all repositories in parallel, and displays the collected output afterwards",
+	Args:  execArgs,
+	Run:   runExecCommand,
+}
+
+func init() {
+	RootCmd.AddCommand(execCmd)
+}
+
+// execArgs validates that the command to run is separated by a dash,
+// and only the optional path precedes it.
+func execArgs(cmd *cobra.Command, args []string) error {
+	dashIdx := cmd.ArgsLenAtDash()
+	if dashIdx == -1 {
+		return errors.New("command must be separated by '--'")
+	}
+	if dashIdx > 1 {
+		return fmt.Errorf("accepts at most 1 path before '--', received %d", dashIdx)
+	}
+	if dashIdx == len(args) {
+		return errors.New("missing command after '--'")
+	}
+	return nil
+}
+
+func runExecCommand(cmd *cobra.Command, args []string) {
+	dashIdx := cmd.ArgsLenAtDash()
+	command := args[dashIdx:]
+
+	repos := loadRepositories(args[:dashIdx])
+
+	fmt.Println()
+
+	w := ui.NewStdoutWriter()
+
+	results := make([]*repo.ExecResult, len(repos))
+
+	w.Render(func() {
+		ui.WriteExecStatus(w, repos, results)
+	})
+
+	forEachRepository(repos, func(idx int, r *repo.Repository) {
+		w.Render(func() {
+			results[idx] = &repo.ExecResult{Running: true}
+			ui.WriteExecStatus(w, repos, results)
+		})
+
+		result := r.Exec(command[0], command[1:]...)
+		w.Render(func() {
+			results[idx] = result
+			ui.WriteExecStatus(w, repos, results)
+		})
+	})
+
+	w.Done()
+
+	ui.WriteExecOutput(os.Stdout, repos, results)
+
+	for _, result := range results {
+		if result.Failed() {
+			os.Exit(1)
+		}
+	}
+}
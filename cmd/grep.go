@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/benweidig/tortuga/repo"
+	"github.com/benweidig/tortuga/ui"
+
+	"github.com/spf13/cobra"
+)
+
+// Arguments of the grep command
+var (
+	countArg      bool
+	ignoreCaseArg bool
+)
+
+var grepCmd = &cobra.Command{
+	Use:   "grep <pattern> [<path>] [-- <pathspec>...]",
+	Short: "Search the tracked files of all repositories",
+	Long:  "Runs 'git grep' in all repositories and prints the matching lines prefixed with 'repo:path:line'",
+	Args:  grepArgs,
+	Run:   runGrepCommand,
+}
+
+func init() {
+	grepCmd.Flags().BoolVarP(&countArg, "count", "c", false, "Only show the count of matching lines per repository")
+	grepCmd.Flags().BoolVarP(&ignoreCaseArg, "ignore-case", "i", false, "Ignore case differences between the pattern and the files")
+	RootCmd.AddCommand(grepCmd)
+}
+
+// grepArgs validates that there's a pattern and at most a path before the optional pathspecs
+func grepArgs(cmd *cobra.Command, args []string) error {
+	dashIdx := cmd.ArgsLenAtDash()
+	if dashIdx == -1 {
+		dashIdx = len(args)
+	}
+	return cobra.RangeArgs(1, 2)(cmd, args[:dashIdx])
+}
+
+func runGrepCommand(cmd *cobra.Command, args []string) {
+	dashIdx := cmd.ArgsLenAtDash()
+	var pathspecs []string
+	if dashIdx != -1 {
+		pathspecs = args[dashIdx:]
+		args = args[:dashIdx]
+	}
+
+	pattern := args[0]
+	repos := loadRepositories(args[1:])
+
+	results := make([]*repo.GrepResult, len(repos))
+
+	if countArg {
+		fmt.Println()
+
+		w := ui.NewStdoutWriter()
+
+		// The hits are added to the status table, which shows the already fetched remote state
+		hits := map[*repo.Repository]*repo.GrepResult{}
+		opts := statusOptions(false, time.Now())
+		opts.Extra = []ui.ExtraColumn{ui.GrepHitsColumn(hits)}
+
+		w.Render(func() {
+			ui.WriteRepositoryStatus(w, repos, opts)
+		})
+
+		forEachRepository(repos, func(idx int, r *repo.Repository) {
+			r.Refresh()
+			result := r.Grep(pattern, ignoreCaseArg, pathspecs...)
+
+			w.Render(func() {
+				results[idx] = result
+				hits[r] = result
+				ui.WriteRepositoryStatus(w, repos, opts)
+			})
+		})
+
+		w.Done()
+	} else {
+		// Matches are streamed as soon as a repository is done,
+		// so the output of different repositories must not interleave
+		var outputMtx sync.Mutex
+
+		forEachRepository(repos, func(idx int, r *repo.Repository) {
+			result := r.Grep(pattern, ignoreCaseArg, pathspecs...)
+
+			outputMtx.Lock()
+			defer outputMtx.Unlock()
+
+			results[idx] = result
+
+			if results[idx].Error != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", r.Name, results[idx].Error)
+				return
+			}
+			ui.WriteGrepMatches(os.Stdout, r, results[idx])
+		})
+	}
+
+	// Like grep itself, exit with 1 if nothing was found
+	for _, result := range results {
+		if result.Error == nil && len(result.Matches) > 0 {
+			return
+		}
+	}
+	os.Exit(1)
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/benweidig/tortuga/history"
+	"github.com/benweidig/tortuga/repo"
+	"github.com/benweidig/tortuga/ui"
+
+	"github.com/spf13/cobra"
+)
+
+// Arguments of the history/undo commands
+var (
+	limitArg int
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the recorded syncs",
+	Long:  "Shows the recorded syncs with the old and new HEAD, the pushed range, and the stash of every repository",
+	Args:  cobra.NoArgs,
+	Run:   runHistoryCommand,
+}
+
+var undoCmd = &cobra.Command{
+	Use:   "undo <run-id>",
+	Short: "Reset all repositories of a recorded sync to their old HEAD",
+	Long:  "Resets the branches of a recorded sync to their HEAD before the sync, if still checked out and part of the reflog. Pushed commits remain on the remote.",
+	Args:  cobra.ExactArgs(1),
+	Run:   runUndoCommand,
+}
+
+func init() {
+	historyCmd.Flags().IntVarP(&limitArg, "limit", "n", 10, "Maximum of syncs to show, newest first")
+	RootCmd.AddCommand(historyCmd)
+
+	undoCmd.Flags().BoolVarP(&yesArg, "yes", "y", false, "Anwser 'Yes' to 'undo' prompt")
+	RootCmd.AddCommand(undoCmd)
+}
+
+// readHistory reads all recorded runs, or exits on error
+func readHistory() []history.Run {
+	historyPath, err := history.DefaultPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Couldn't determinate history file: '%s'.\n", err)
+		os.Exit(1)
+	}
+
+	runs, err := history.Read(historyPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Couldn't read history: '%s'.\n", err)
+		os.Exit(1)
+	}
+
+	return runs
+}
+
+// recordHistory appends the run to the history file. Failing to do so isn't fatal for a sync.
+func recordHistory(run history.Run) {
+	if len(run.Entries) == 0 {
+		return
+	}
+
+	historyPath, err := history.DefaultPath()
+	if err == nil {
+		err = history.Append(historyPath, run)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Couldn't record history: '%s'.\n", err)
+	}
+}
+
+func runHistoryCommand(_ *cobra.Command, _ []string) {
+	runs := readHistory()
+
+	if len(runs) == 0 {
+		fmt.Fprintln(os.Stderr, "No syncs recorded.")
+		return
+	}
+
+	// Newest first
+	for i, j := 0, len(runs)-1; i < j; i, j = i+1, j-1 {
+		runs[i], runs[j] = runs[j], runs[i]
+	}
+	if limitArg > 0 && len(runs) > limitArg {
+		runs = runs[:limitArg]
+	}
+
+	fmt.Println()
+	ui.WriteHistory(os.Stdout, runs)
+}
+
+func runUndoCommand(_ *cobra.Command, args []string) {
+	run, err := history.Find(readHistory(), args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Couldn't undo '%s': %s.\n", args[0], err)
+		os.Exit(1)
+	}
+
+	// Only repositories with a changed HEAD can be undone
+	var entries []history.Entry
+	var repos []*repo.Repository
+	for _, entry := range run.Entries {
+		if entry.OldHead == entry.NewHead || len(entry.OldHead) == 0 {
+			continue
+		}
+
+		// Errors are ignored, we only need the current branch
+		r, _ := newRepository(entry.Path)
+		entries = append(entries, entry)
+		repos = append(repos, r)
+	}
+
+	if len(repos) == 0 {
+		fmt.Fprintf(os.Stderr, "Nothing to undo for '%s'.\n", run.ID)
+		return
+	}
+
+	fmt.Println()
+
+	w := ui.NewStdoutWriter()
+
+	results := make([]*repo.UndoResult, len(repos))
+
+	w.Render(func() {
+		ui.WriteUndoStatus(w, repos, entries, results)
+	})
+
+	w.Done()
+
+	if !yesArg && !confirm(w, fmt.Sprintf("Reset %s repositories?", ui.PromptCount(len(repos), ""))) {
+		os.Exit(0)
+	}
+
+	w.Reset()
+
+	forEachRepository(repos, func(idx int, r *repo.Repository) {
+		result := r.Undo(entries[idx].Branch, entries[idx].OldHead)
+
+		w.Render(func() {
+			results[idx] = result
+			ui.WriteUndoStatus(w, repos, entries, results)
+		})
+	})
+
+	w.Done()
+
+	for _, result := range results {
+		if result.State == repo.UndoStateError {
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/benweidig/tortuga/repo"
+	"github.com/benweidig/tortuga/ui"
+
+	"github.com/spf13/cobra"
+)
+
+// Arguments of the log command
+var (
+	sinceArg  string
+	authorArg string
+	outputArg string
+)
+
+var logCmd = &cobra.Command{
+	Use:   "log [<path>]",
+	Short: "Show the recent commits of all repositories",
+	Long:  "Fetches and gathers the recent commits of the current branch and its upstream of all repositories, interleaved chronologically",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   runLogCommand,
+}
+
+func init() {
+	logCmd.Flags().StringVar(&sinceArg, "since", "yesterday", "Show commits more recent than the date, in any format git understands")
+	logCmd.Flags().StringVar(&authorArg, "author", "", "Show only commits with an author matching the pattern")
+	logCmd.Flags().StringVarP(&outputArg, "output", "o", "table", "Output format: table or json")
+	RootCmd.AddCommand(logCmd)
+}
+
+func runLogCommand(_ *cobra.Command, args []string) {
+	if outputArg != "table" && outputArg != "json" {
+		fmt.Fprintf(os.Stderr, "Invalid output format: '%s'.\n", outputArg)
+		os.Exit(1)
+	}
+
+	repos := loadRepositories(args)
+
+	results := make([]*repo.LogResult, len(repos))
+
+	// JSON output needs to be parseable, so there's no live table
+	if outputArg == "json" {
+		forEachRepository(repos, func(idx int, r *repo.Repository) {
+			results[idx] = r.Log(sinceArg, authorArg)
+		})
+
+		for idx, result := range results {
+			if result.Error != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", repos[idx].Name, result.Error)
+			}
+		}
+
+		err := ui.WriteLogJSON(os.Stdout, repo.InterleaveCommits(results))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Couldn't write JSON: '%s'.\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Println()
+
+	w := ui.NewStdoutWriter()
+
+	w.Render(func() {
+		ui.WriteLogStatus(w, repos, results)
+	})
+
+	forEachRepository(repos, func(idx int, r *repo.Repository) {
+		result := r.Log(sinceArg, authorArg)
+
+		w.Render(func() {
+			results[idx] = result
+			ui.WriteLogStatus(w, repos, results)
+		})
+	})
+
+	w.Done()
+
+	ui.WriteLog(os.Stdout, repo.InterleaveCommits(results))
+}
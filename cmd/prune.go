@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/benweidig/tortuga/repo"
+	"github.com/benweidig/tortuga/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune [<path>]",
+	Short: "Delete merged and gone local branches",
+	Long:  "Fetches with pruning and deletes local branches that are merged (or squash-merged) into the default branch, or whose upstream is gone",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   runPruneCommand,
+}
+
+func init() {
+	pruneCmd.Flags().BoolVarP(&yesArg, "yes", "y", false, "Anwser 'Yes' to 'delete' prompt")
+	RootCmd.AddCommand(pruneCmd)
+}
+
+func runPruneCommand(_ *cobra.Command, args []string) {
+	repos := loadRepositories(args)
+
+	fmt.Println()
+
+	w := ui.NewStdoutWriter()
+
+	// /////////////////////////////////////////////////////////////////////////
+	// Step 1: Find prunable branches
+	// /////////////////////////////////////////////////////////////////////////
+
+	results := make([]*repo.PruneResult, len(repos))
+
+	w.Render(func() {
+		ui.WriteRepositoryPrunableBranches(w, repos, results)
+	})
+
+	forEachRepository(repos, func(idx int, r *repo.Repository) {
+		result := r.FindPrunableBranches()
+
+		w.Render(func() {
+			results[idx] = result
+			ui.WriteRepositoryPrunableBranches(w, repos, results)
+		})
+	})
+
+	w.Done()
+
+	prunable := 0
+	for _, result := range results {
+		if result.Error == nil {
+			prunable += len(result.Branches)
+		}
+	}
+
+	if prunable == 0 {
+		os.Exit(0)
+	}
+
+	// /////////////////////////////////////////////////////////////////////////
+	// Step 2: Ask for confirmation
+	// /////////////////////////////////////////////////////////////////////////
+
+	if !yesArg && !confirm(w, fmt.Sprintf("Delete %s branches?", ui.PromptCount(prunable, ""))) {
+		os.Exit(0)
+	}
+
+	// /////////////////////////////////////////////////////////////////////////
+	// Step 3: Delete the branches
+	// /////////////////////////////////////////////////////////////////////////
+
+	w.Reset()
+
+	forEachRepository(repos, func(idx int, r *repo.Repository) {
+		// Deleting changes the result, which is rendered by the other workers meanwhile
+		result := *results[idx]
+		if result.Error == nil && len(result.Branches) > 0 {
+			r.DeleteBranches(&result)
+		}
+
+		w.Render(func() {
+			results[idx] = &result
+			ui.WriteRepositoryPrunableBranches(w, repos, results)
+		})
+	})
+
+	w.Done()
+
+	fmt.Println()
+}
+
+// confirm asks a yes/no question, defaulting to no
+func confirm(w *ui.StdoutWriter, question string) bool {
+	w.Flush()
+
+	fmt.Fprintf(w, "%s %s [y/N] ", ui.PromptMarker(), question)
+	w.Flush()
+
+	r := bufio.NewReader(os.Stdin)
+
+	answer, err := r.ReadString('\n')
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Couldn't get prompt answer: '%s'.\n", err)
+		os.Exit(1)
+	}
+
+	w.AddLineBreaks(1)
+
+	answer = strings.TrimSpace(strings.ToLower(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -5,10 +5,16 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/benweidig/tortuga/config"
 	"github.com/benweidig/tortuga/git"
+	"github.com/benweidig/tortuga/history"
+	"github.com/benweidig/tortuga/notify"
 	"github.com/benweidig/tortuga/repo"
 	"github.com/benweidig/tortuga/ui"
 	"github.com/benweidig/tortuga/version"
@@ -21,63 +27,198 @@ import (
 var (
 	monochromeArg bool
 	yesArg        bool
+	jobsArg       int
+	filterArg     []string
+	backendArg    string
+	configArg     string
+	retriesArg    int
+	timingsArg    bool
+	sortArg       string
+	groupByArg    string
+	onlyDirtyArg  bool
+	plainArg      bool
+	columnsArg    []string
+	themeArg      string
+	reportArg     string
+
+	// reportFileArg is the positional argument following the format of --report
+	reportFileArg string
 )
 
+// cfg is the loaded configuration file
+var cfg *config.Config
+
 // RootCmd is the only command, so this is Tortuga
 var RootCmd = &cobra.Command{
 	Version: version.BuildVersion(),
-	Use:     "tt",
+	Use:     "tt [--report <format> <file>] [<path>]",
 	Short:   "Tortuga",
-	Args:    cobra.MaximumNArgs(1),
+	Args:    rootArgs,
 	Long:    "CLI tool for fetching/rebasing multiple git repositories at once",
 	Run:     runCommand,
+
+	PersistentPreRun: prepare,
 }
 
 func init() {
-	RootCmd.Flags().BoolVarP(&monochromeArg, "monochrome", "m", false, "Monochrome output, no ANSI colorize")
+	RootCmd.PersistentFlags().BoolVarP(&monochromeArg, "monochrome", "m", false, "Monochrome output, no ANSI colorize")
+	RootCmd.PersistentFlags().IntVarP(&jobsArg, "jobs", "j", 16, "Maximum of repositories to process in parallel")
+	RootCmd.PersistentFlags().StringSliceVarP(&filterArg, "filter", "f", nil, "Only include repositories with a name matching the glob pattern")
+	RootCmd.PersistentFlags().IntVar(&retriesArg, "retries", 2, "Maximum of retries of fetches and pushes failed by network errors")
+	RootCmd.PersistentFlags().StringVar(&configArg, "config", "", "Config file (default: $XDG_CONFIG_HOME/tortuga/config.json)")
+	RootCmd.PersistentFlags().BoolVar(&plainArg, "plain", false, "Plain output without redrawing, the default if not writing to a terminal")
+	RootCmd.PersistentFlags().StringVar(&themeArg, "theme", "", "Theme: "+strings.Join(ui.Themes, ", ")+" (default: from config, or default)")
+	RootCmd.PersistentFlags().StringVar(&backendArg, "backend", "exec", "Git backend: exec, or go-git for in-process read-only operations")
 	RootCmd.Flags().BoolVarP(&yesArg, "yes", "y", false, "Anwser 'Yes' to 'sync' prompt")
+	RootCmd.Flags().BoolVar(&timingsArg, "timings", false, "Show how long each repository took")
+	RootCmd.Flags().StringVar(&sortArg, "sort", "name", "Sort by: "+strings.Join(ui.SortOrders, ", "))
+	RootCmd.Flags().StringVar(&groupByArg, "group-by", "", "Group by: "+strings.Join(ui.Groupings, ", "))
+	RootCmd.Flags().BoolVar(&onlyDirtyArg, "only-dirty", false, "Only show repositories needing attention")
+	RootCmd.Flags().StringSliceVar(&columnsArg, "columns", nil, "Additional columns: "+strings.Join(ui.Columns, ", "))
+	RootCmd.Flags().StringVar(&reportArg, "report", "", "Write a report of the final status to the file following the format: "+strings.Join(ui.ReportFormats, ", "))
 }
 
-func runCommand(_ *cobra.Command, args []string) {
-
-	// /////////////////////////////////////////////////////////////////////////
-	// Step 1: Parse arguments and prepare requirements
-	// /////////////////////////////////////////////////////////////////////////
+// rootArgs validates the optional path, which is preceded by the file of a report
+func rootArgs(cmd *cobra.Command, args []string) error {
+	if len(reportArg) == 0 {
+		return cobra.MaximumNArgs(1)(cmd, args)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("--report %s needs a file", reportArg)
+	}
+	return cobra.RangeArgs(1, 2)(cmd, args)
+}
 
-	// Determinate the directory to check.
-	var basePath string
+func prepare(_ *cobra.Command, _ []string) {
+	if backendArg != "exec" && backendArg != "go-git" {
+		fmt.Fprintf(os.Stderr, "Invalid backend: '%s'.\n", backendArg)
+		os.Exit(1)
+	}
 
-	// There can only be 0 or 1 arguments, so this check is enough
-	if len(args) == 1 {
-		basePath = args[0]
-	} else {
-		// Falback to actual working directory
-		wd, err := os.Getwd()
+	configPath := configArg
+	if len(configPath) == 0 {
+		defaultPath, err := config.DefaultPath()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Couldn't retrieve working directory: '%s'.\n", err)
+			fmt.Fprintf(os.Stderr, "Couldn't determinate config directory: '%s'.\n", err)
 			os.Exit(1)
 		}
-		basePath = wd
+		configPath = defaultPath
+	}
+
+	var err error
+	cfg, err = config.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Couldn't load config '%s': '%s'.\n", configPath, err)
+		os.Exit(1)
 	}
 
+	// The environment must be set before any git command runs
+	git.SetEnvironment(git.Environment{
+		Vars:          cfg.GitEnv(),
+		ForRepository: cfg.RepositoryGitEnv,
+	})
+
 	// Disable colors if requested either via arg or env, see http://no-color.org/.
 	// The color library might disable color nontheless if it thinks the terminal isn't
 	// supporting it.
 	_, noColorEnvExists := os.LookupEnv("NO_COLOR")
 	monochromeArg = monochromeArg || noColorEnvExists
+
+	// Old Windows consoles show escape sequences verbatim instead of colors
+	if !ui.EnableEscapeSequences() {
+		monochromeArg = true
+	}
 	if monochromeArg {
 		gchalk.SetLevel(gchalk.LevelNone)
 	}
 
+	ui.SetPlain(plainArg)
+
+	theme, err := loadTheme()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid theme: '%s'.\n", err)
+		os.Exit(1)
+	}
+	ui.SetTheme(theme)
+}
+
+// loadTheme returns the theme requested by argument or config, with the colors and symbols of the config
+func loadTheme() (*ui.Theme, error) {
+	name := themeArg
+	if len(name) == 0 {
+		name = cfg.Theme
+	}
+	if len(name) == 0 {
+		name = "default"
+	}
+
+	theme, err := ui.NewTheme(name)
+	if err != nil {
+		return nil, err
+	}
+
+	for role, styles := range cfg.Colors {
+		err = theme.SetColor(role, styles...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for symbolName, symbol := range cfg.Symbols {
+		err = theme.SetSymbol(symbolName, symbol)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return theme, nil
+}
+
+func runCommand(_ *cobra.Command, args []string) {
+	if !slices.Contains(ui.SortOrders, sortArg) {
+		fmt.Fprintf(os.Stderr, "Invalid sort order: '%s'.\n", sortArg)
+		os.Exit(1)
+	}
+
+	if len(groupByArg) > 0 && !slices.Contains(ui.Groupings, groupByArg) {
+		fmt.Fprintf(os.Stderr, "Invalid grouping: '%s'.\n", groupByArg)
+		os.Exit(1)
+	}
+
+	for _, column := range columnsArg {
+		if !slices.Contains(ui.Columns, column) {
+			fmt.Fprintf(os.Stderr, "Invalid column: '%s'.\n", column)
+			os.Exit(1)
+		}
+	}
+
+	if len(reportArg) > 0 {
+		if !slices.Contains(ui.ReportFormats, reportArg) {
+			fmt.Fprintf(os.Stderr, "Invalid report format: '%s'.\n", reportArg)
+			os.Exit(1)
+		}
+
+		reportFileArg = args[0]
+		args = args[1:]
+
+		// A directory is most likely the path, given before the file
+		if info, err := os.Stat(reportFileArg); err == nil && info.IsDir() {
+			fmt.Fprintf(os.Stderr, "Invalid report file: '%s' is a directory, the file follows the format: --report %s <file>.\n", reportFileArg, reportArg)
+			os.Exit(1)
+		}
+	}
+
+	runStarted := time.Now()
+
 	// /////////////////////////////////////////////////////////////////////////
-	// Step 2: Find repositories
+	// Step 1 + 2: Parse arguments and find repositories
 	// /////////////////////////////////////////////////////////////////////////
 
-	repos, _ := findRepositories(basePath)
+	repos := loadRepositories(args)
 
-	if len(repos) == 0 {
-		fmt.Fprintf(os.Stderr, "No repositories found at '%s'.\n", basePath)
-		os.Exit(1)
+	details := ui.ColumnDetails(columnsArg)
+	for _, r := range repos {
+		r.Details = details
 	}
 
 	// /////////////////////////////////////////////////////////////////////////
@@ -89,21 +230,18 @@ func runCommand(_ *cobra.Command, args []string) {
 	// Start live writer which we will use throughout the rendering
 	w := ui.NewStdoutWriter()
 
-	updateRepositories(repos, w)
+	updateRepositories(repos, w, runStarted)
 
 	// /////////////////////////////////////////////////////////////////////////
 	// Step 4: Check if we can sync at all
 	// /////////////////////////////////////////////////////////////////////////
 
-	incoming := 0
-	outgoing := 0
-
-	for _, r := range repos {
-		incoming += r.Incoming
-		outgoing += r.Outgoing
-	}
+	summary := repo.Summarize(repos, false)
+	incoming := summary.Incoming
+	outgoing := summary.Outgoing
 
 	if incoming == 0 && outgoing == 0 {
+		finishRun(repos, false, runStarted)
 		os.Exit(0)
 	}
 
@@ -124,14 +262,14 @@ func runCommand(_ *cobra.Command, args []string) {
 
 			prompt := ""
 			if incoming > 0 {
-				prompt += gchalk.WithBrightYellow().Sprintf(" %d↓", incoming)
+				prompt += " " + ui.PromptCount(incoming, ui.CurrentTheme().Incoming)
 			}
 
 			if outgoing > 0 {
-				prompt += gchalk.WithBrightYellow().Sprintf(" %d↑", outgoing)
+				prompt += " " + ui.PromptCount(outgoing, ui.CurrentTheme().Outgoing)
 			}
 
-			fmt.Fprintf(w, "%s Sync Changes?%s [Y/n/i/?] ", gchalk.WithWhite().Bold(">>>"), prompt)
+			fmt.Fprintf(w, "%s Sync Changes?%s [Y/n/i/?] ", ui.PromptMarker(), prompt)
 			w.Flush()
 
 			r := bufio.NewReader(os.Stdin)
@@ -152,6 +290,7 @@ func runCommand(_ *cobra.Command, args []string) {
 			}
 
 			if answer == "n" {
+				finishRun(repos, false, runStarted)
 				os.Exit(0)
 			} else if answer == "i" {
 				syncIncomingOnly = true
@@ -177,16 +316,118 @@ func runCommand(_ *cobra.Command, args []string) {
 	// Step 5b: Do the actual sync
 	// /////////////////////////////////////////////////////////////////////////
 
-	syncRepositories(repos, syncIncomingOnly, w)
+	started := time.Now()
+
+	syncRepositories(repos, syncIncomingOnly, w, runStarted)
+
+	recordHistory(history.NewRun(started, syncIncomingOnly, repos))
+
+	finishRun(repos, syncIncomingOnly, runStarted)
 
 	fmt.Println()
 }
 
+// finishRun writes the report and notifies the hooks, if requested
+func finishRun(repos []*repo.Repository, incomingOnly bool, started time.Time) {
+	writeReport(repos, incomingOnly, started)
+	notifyHooks(repos)
+}
+
+// writeReport writes the report of the final status, if requested, and tells where
+func writeReport(repos []*repo.Repository, incomingOnly bool, started time.Time) {
+	if len(reportArg) == 0 {
+		return
+	}
+
+	reportFile := reportFileArg
+
+	f, err := os.Create(reportFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Couldn't create report '%s': '%s'.\n", reportFile, err)
+		os.Exit(1)
+	}
+
+	opts := statusOptions(incomingOnly, started)
+
+	err = ui.WriteReport(f, reportArg, repos, opts, time.Now())
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Couldn't write report '%s': '%s'.\n", reportFile, err)
+		os.Exit(1)
+	}
+
+	// Relative to the working directory, which isn't necessarily the path of the repositories
+	if absReportFile, err := filepath.Abs(reportFile); err == nil {
+		reportFile = absReportFile
+	}
+	fmt.Printf("\nReport written to '%s'.\n", reportFile)
+}
+
+// resolveBasePath determinates the directory to check from the optional path argument
+func resolveBasePath(args []string) string {
+	// There can only be 0 or 1 arguments, so this check is enough
+	if len(args) == 1 {
+		return args[0]
+	}
+
+	// Falback to actual working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Couldn't retrieve working directory: '%s'.\n", err)
+		os.Exit(1)
+	}
+	return wd
+}
+
+// loadRepositories determinates the base path from the optional path argument,
+// and returns the filtered repositories found there. Exits if there are none.
+func loadRepositories(args []string) []*repo.Repository {
+	basePath := resolveBasePath(args)
+
+	repos, _ := findRepositories(basePath)
+
+	repos, err := filterRepositories(repos, filterArg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid filter: '%s'.\n", err)
+		os.Exit(1)
+	}
+
+	if len(repos) == 0 {
+		fmt.Fprintf(os.Stderr, "No repositories found at '%s'.\n", basePath)
+		os.Exit(1)
+	}
+
+	return repos
+}
+
+// newRepository creates a Repository using the requested git backend
+func newRepository(repoPath string) (*repo.Repository, error) {
+	var backend git.Backend = git.NewExecBackend()
+	if backendArg == "go-git" {
+		backend = git.NewGoGitBackend()
+	}
+
+	r, err := repo.NewRepositoryWithBackend(repoPath, backend)
+	r.MaxRetries = retriesArg
+	r.Tags = cfg.RepositoryTags(repoPath)
+
+	hooks := cfg.RepositoryHooks(repoPath)
+	r.Hooks = repo.Hooks{
+		PostPull: hooks.PostPull,
+		PrePush:  hooks.PrePush,
+		PostSync: hooks.PostSync,
+	}
+	return r, err
+}
+
 func findRepositories(basePath string) ([]*repo.Repository, error) {
 	var repos []*repo.Repository
 
 	if git.IsRepo(basePath) {
-		r, err := repo.NewRepository(basePath)
+		r, err := newRepository(basePath)
 		repos = append(repos, r)
 		return repos, err
 	}
@@ -209,43 +450,136 @@ func findRepositories(basePath string) ([]*repo.Repository, error) {
 		}
 
 		// Build repository. We ignore errors so all will be displayed
-		r, _ := repo.NewRepository(entryPath)
+		r, _ := newRepository(entryPath)
 		repos = append(repos, r)
 	}
 
 	return repos, nil
 }
 
-func updateRepositories(repos []*repo.Repository, w *ui.StdoutWriter) {
+// filterRepositories returns only the repositories with a name matching at least one
+// of the glob patterns. No patterns means no filtering at all.
+func filterRepositories(repos []*repo.Repository, patterns []string) ([]*repo.Repository, error) {
+	if len(patterns) == 0 {
+		return repos, nil
+	}
+
+	var filtered []*repo.Repository
+	for _, r := range repos {
+		for _, pattern := range patterns {
+			matched, err := path.Match(pattern, r.Name)
+			if err != nil {
+				return nil, err
+			}
+			if matched {
+				filtered = append(filtered, r)
+				break
+			}
+		}
+	}
+
+	return filtered, nil
+}
 
-	// 2. Initial output showing all repos
-	w.Render(func() {
-		ui.WriteRepositoryStatus(w, repos, false)
+// forEachRepository runs fn for all repositories in parallel, but never more than
+// the requested amount of jobs at once. Returns after all are done.
+func forEachRepository(repos []*repo.Repository, fn func(idx int, r *repo.Repository)) {
+	forEach(len(repos), func(idx int) {
+		fn(idx, repos[idx])
 	})
+}
 
-	// 3. Start a waitgroup
-	var wg sync.WaitGroup
-	wg.Add(len(repos))
+// forEach runs fn for each index up to count in parallel, limited by the jobs argument
+func forEach(count int, fn func(idx int)) {
+	jobs := jobsArg
+	if jobs < 1 {
+		jobs = 1
+	}
 
-	// 4. Iterate over the repos and parallel check/update the repos and update the output
-	for idx := range repos {
-		r := repos[idx]
-		go func() {
-			r.Update()
+	// The semaphore limits the concurrently running goroutines
+	sem := make(chan struct{}, jobs)
 
-			w.Render(func() {
-				ui.WriteRepositoryStatus(w, repos, false)
-			})
+	var wg sync.WaitGroup
+	wg.Add(count)
 
-			wg.Done()
+	for idx := 0; idx < count; idx++ {
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(idx)
 		}()
 	}
 
-	// 5. Wait for all goroutines to finish
 	wg.Wait()
 }
 
-func syncRepositories(repos []*repo.Repository, incomingOnly bool, w *ui.StdoutWriter) {
+// notifyHooks notifies the configured hooks about the repositories. Failing to do so isn't fatal for a run.
+func notifyHooks(repos []*repo.Repository) {
+	if len(cfg.Notify) == 0 {
+		return
+	}
+
+	summary := notify.NewSummary(repos)
+	for _, hook := range cfg.Notify {
+		err := summary.Notify(hook)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Couldn't notify hook: '%s'.\n", err)
+		}
+	}
+}
+
+// statusOptions returns the options of the status table, as requested by the arguments.
+// The elapsed time of the summary is counted from the start of the run.
+func statusOptions(incomingOnly bool, runStarted time.Time) ui.StatusOptions {
+	return ui.StatusOptions{
+		IncomingOnly: incomingOnly,
+		Timings:      timingsArg,
+		Started:      runStarted,
+		Sort:         sortArg,
+		GroupBy:      groupByArg,
+		OnlyDirty:    onlyDirtyArg,
+		Columns:      columnsArg,
+	}
+}
+
+func updateRepositories(repos []*repo.Repository, w *ui.StdoutWriter, runStarted time.Time) {
+	opts := statusOptions(false, runStarted)
+
+	render := func() {
+		ui.WriteRepositoryStatus(w, repos, opts)
+	}
+
+	// 2. Initial output showing all repos, animated until all are done
+	w.Render(render)
+	stopAnimation := w.Animate(ui.SpinnerInterval, render)
+
+	// 3. Iterate over the groups of repos sharing objects and parallel check/update them and update the output.
+	//    The repos of a group are updated one after another, so shared objects are only fetched once.
+	groups := repo.GroupRepositories(repos)
+
+	forEach(len(groups), func(idx int) {
+		group := groups[idx]
+		for _, r := range group.Repositories {
+			r.OnRetry = func() {
+				w.Event(ui.RepositoryEvent(r, false))
+				w.Render(render)
+			}
+
+			group.Update(r)
+
+			w.Event(ui.RepositoryEvent(r, false))
+			w.Render(render)
+		}
+	})
+
+	stopAnimation()
+	w.Done()
+}
+
+func syncRepositories(repos []*repo.Repository, incomingOnly bool, w *ui.StdoutWriter, runStarted time.Time) {
 	for idx := range repos {
 		r := repos[idx]
 
@@ -262,32 +596,32 @@ func syncRepositories(repos []*repo.Repository, incomingOnly bool, w *ui.StdoutW
 	}
 
 	// 2. Reset live writer and render the repositories
+	opts := statusOptions(incomingOnly, runStarted)
+
+	render := func() {
+		ui.WriteRepositoryStatus(w, repos, opts)
+	}
+
 	w.Reset()
-	ui.WriteRepositoryStatus(w, repos, incomingOnly)
+	ui.WriteRepositoryStatus(w, repos, opts)
 
-	// 3. Do the work async for better speed
-	var wg sync.WaitGroup
-	wg.Add(len(repos))
+	stopAnimation := w.Animate(ui.SpinnerInterval, render)
 
-	for idx := range repos {
-		r := repos[idx]
-		if r.State != repo.StateNeedsSync {
-			w.Render(func() {
-				ui.WriteRepositoryStatus(w, repos, incomingOnly)
-			})
-			wg.Done()
-			continue
+	// 3. Do the work async for better speed
+	forEachRepository(repos, func(_ int, r *repo.Repository) {
+		r.OnRetry = func() {
+			w.Event(ui.RepositoryEvent(r, incomingOnly))
+			w.Render(render)
 		}
 
-		go func() {
+		if r.State == repo.StateNeedsSync {
 			r.Sync(incomingOnly)
+			w.Event(ui.RepositoryEvent(r, incomingOnly))
+		}
 
-			w.Render(func() {
-				ui.WriteRepositoryStatus(w, repos, incomingOnly)
-			})
+		w.Render(render)
+	})
 
-			wg.Done()
-		}()
-	}
-	wg.Wait()
+	stopAnimation()
+	w.Done()
 }
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/benweidig/tortuga/git"
+	"github.com/benweidig/tortuga/repo"
+	"github.com/benweidig/tortuga/ui"
+
+	"github.com/spf13/cobra"
+)
+
+// Arguments of the watch command
+var (
+	watchIntervalArg time.Duration
+)
+
+// pollInterval is how often the repositories are checked for local changes
+const pollInterval = 2 * time.Second
+
+var watchCmd = &cobra.Command{
+	Use:   "watch [<path>]",
+	Short: "Periodically fetch and display the status of all repositories",
+	Long:  "Keeps the status table on screen, periodically fetches all repositories, and refreshes repositories immediately if their HEAD or index changes",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   runWatchCommand,
+}
+
+func init() {
+	watchCmd.Flags().DurationVar(&watchIntervalArg, "interval", 5*time.Minute, "Interval between fetches")
+	RootCmd.AddCommand(watchCmd)
+}
+
+// repositorySnapshot holds the values of a repository that are compared between updates
+type repositorySnapshot struct {
+	branch      string
+	state       repo.State
+	incoming    int
+	outgoing    int
+	changes     int
+	unversioned int
+}
+
+func snapshotRepository(r *repo.Repository) repositorySnapshot {
+	return repositorySnapshot{
+		branch:      r.Branch,
+		state:       r.State,
+		incoming:    r.Incoming,
+		outgoing:    r.Outgoing,
+		changes:     r.Changes,
+		unversioned: r.Unversioned,
+	}
+}
+
+// newlyNeedingAttention returns the repositories with more incoming commits, or a new error, than in their snapshots
+func newlyNeedingAttention(previous []repositorySnapshot, repos []*repo.Repository) []*repo.Repository {
+	var changed []*repo.Repository
+	for idx, r := range repos {
+		newError := r.State == repo.StateError && previous[idx].state != repo.StateError
+		newIncoming := r.State != repo.StateError && r.Incoming > previous[idx].incoming
+		if newError || newIncoming {
+			changed = append(changed, r)
+		}
+	}
+	return changed
+}
+
+// resolveGitDir returns the git dir of a repository, which holds the HEAD and index of the working tree.
+// For worktrees, it's not the ".git" file of the working tree.
+func resolveGitDir(repoPath string) string {
+	gitDir, err := git.GitDir(repoPath)
+	if err != nil {
+		return filepath.Join(repoPath, ".git")
+	}
+	return gitDir
+}
+
+// gitModTime returns the latest modification time of the HEAD and index in the git dir of a repository
+func gitModTime(gitDir string) time.Time {
+	var latest time.Time
+	for _, name := range []string{"HEAD", "index"} {
+		stat, err := os.Stat(filepath.Join(gitDir, name))
+		if err == nil && stat.ModTime().After(latest) {
+			latest = stat.ModTime()
+		}
+	}
+	return latest
+}
+
+// checkInterval exits if the interval between fetches is shorter than the minimum
+func checkInterval(interval time.Duration, minimum time.Duration) {
+	if interval < minimum {
+		fmt.Fprintf(os.Stderr, "Interval must be at least %s.\n", minimum)
+		os.Exit(1)
+	}
+}
+
+func runWatchCommand(_ *cobra.Command, args []string) {
+	checkInterval(watchIntervalArg, pollInterval)
+
+	repos := loadRepositories(args)
+
+	fmt.Println()
+
+	w := ui.NewStdoutWriter()
+
+	rows := make([]ui.WatchRow, len(repos))
+	modTimes := make([]time.Time, len(repos))
+
+	gitDirs := make([]string, len(repos))
+	for idx, r := range repos {
+		gitDirs[idx] = resolveGitDir(r.Path())
+	}
+
+	render := func() {
+		ui.WriteWatchStatus(w, repos, rows, watchIntervalArg)
+	}
+
+	// refresh replaces the repository with a fresh one, so no state is carried over
+	// from the previous update. Only the fetch is optional.
+	refresh := func(idx int, fetch bool) {
+		fresh, _ := newRepository(repos[idx].Path())
+		if fetch {
+			fresh.Update()
+		} else {
+			fresh.Refresh()
+		}
+
+		// Updating the repository might touch the index
+		modTime := gitModTime(gitDirs[idx])
+
+		w.Render(func() {
+			previous := repos[idx]
+			changed := !rows[idx].Updated.IsZero() && snapshotRepository(previous) != snapshotRepository(fresh)
+
+			repos[idx] = fresh
+			rows[idx] = ui.WatchRow{
+				Updated: time.Now(),
+				Changed: changed,
+			}
+			modTimes[idx] = modTime
+
+			render()
+		})
+	}
+
+	w.Render(render)
+
+	// Notifying might take until the timeout of the hooks, so it doesn't block fetching and redrawing.
+	// The notifications are still sent in order.
+	notifications := make(chan []*repo.Repository, 16)
+	go func() {
+		for changed := range notifications {
+			notifyHooks(changed)
+		}
+	}()
+
+	// Without redrawing, the table is written once after each round.
+	// The hooks are only notified of what's new since the previous round.
+	fetchAll := func() {
+		previous := make([]repositorySnapshot, len(repos))
+		for idx, r := range repos {
+			previous[idx] = snapshotRepository(r)
+		}
+
+		forEachRepository(repos, func(idx int, _ *repo.Repository) {
+			refresh(idx, true)
+		})
+		w.Done()
+
+		if changed := newlyNeedingAttention(previous, repos); len(changed) > 0 {
+			notifications <- changed
+		}
+	}
+
+	fetchAll()
+
+	fetchTicker := time.NewTicker(watchIntervalArg)
+	defer fetchTicker.Stop()
+
+	pollTicker := time.NewTicker(pollInterval)
+	defer pollTicker.Stop()
+
+	for {
+		select {
+		case <-fetchTicker.C:
+			fetchAll()
+
+		case <-pollTicker.C:
+			for idx := range repos {
+				if gitModTime(gitDirs[idx]).After(modTimes[idx]) {
+					refresh(idx, false)
+				}
+			}
+			w.Done()
+		}
+	}
+}
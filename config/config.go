@@ -0,0 +1,219 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Config is the optional configuration file of Tortuga
+type Config struct {
+	// Env holds environment variables for all git commands, e.g. proxy variables
+	Env map[string]string `json:"env"`
+
+	// AskPass is the program git asks for credentials, see GIT_ASKPASS
+	AskPass string `json:"askPass"`
+
+	// SSHBatchMode disables all interactive SSH prompts, enabled by default
+	SSHBatchMode *bool `json:"sshBatchMode"`
+
+	// Repositories holds the repository specific configuration, by glob pattern of their name
+	Repositories map[string]RepositoryConfig `json:"repositories"`
+
+	// Theme is the name of the built-in theme
+	Theme string `json:"theme"`
+
+	// Colors override the colors of the theme, by role
+	Colors map[string][]string `json:"colors"`
+
+	// Symbols override the symbols of the theme, by name
+	Symbols map[string]string `json:"symbols"`
+
+	// Notify are the hooks notified after a run, if repositories need attention
+	Notify []NotifyConfig `json:"notify"`
+
+	// Hooks are run while syncing any repository, unless overridden for a repository
+	Hooks HooksConfig `json:"hooks"`
+}
+
+// HooksConfig are the commands run by the shell in the working tree of a repository while syncing it
+type HooksConfig struct {
+	// PostPull runs after incoming commits were applied
+	PostPull string `json:"postPull"`
+
+	// PrePush runs before pushing, a failure prevents the push
+	PrePush string `json:"prePush"`
+
+	// PostSync runs after a successful sync
+	PostSync string `json:"postSync"`
+}
+
+// NotifyConfig is a hook notified with a summary of the run, by a command, a webhook, or both
+type NotifyConfig struct {
+	// Events the hook is notified of, "incoming" and "errors", or all if empty
+	Events []string `json:"events"`
+
+	// Command is run by the shell, with the summary as JSON on its StdIn
+	Command string `json:"command"`
+
+	// Webhook is an URL the summary is POSTed to as JSON
+	Webhook string `json:"webhook"`
+}
+
+// RepositoryConfig is the configuration of all repositories matching a pattern
+type RepositoryConfig struct {
+	// Env holds environment variables for git commands, e.g. a GIT_SSH_COMMAND using a different key
+	Env map[string]string `json:"env"`
+
+	// Tags are used for grouping the repositories, e.g. by team or project
+	Tags []string `json:"tags"`
+
+	// Hooks override the global hooks, e.g. to run "npm ci" after pulling
+	Hooks HooksConfig `json:"hooks"`
+}
+
+// DefaultPath returns the path of the config in the user's config directory,
+// e.g. $XDG_CONFIG_HOME/tortuga/config.json
+func DefaultPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "tortuga", "config.json"), nil
+}
+
+// Load reads the config file. A missing file is an empty config.
+func Load(filePath string) (*Config, error) {
+	c := &Config{}
+
+	data, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, c)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// GitEnv returns the environment variables for all git commands
+func (c *Config) GitEnv() []string {
+	var env []string
+
+	// BatchMode only works if the user doesn't use a custom SSH command already
+	_, sshCommandExists := os.LookupEnv("GIT_SSH_COMMAND")
+	_, sshExists := os.LookupEnv("GIT_SSH")
+	if c.batchMode() && !sshCommandExists && !sshExists {
+		env = append(env, "GIT_SSH_COMMAND="+batchModeSSHCommand("ssh"))
+	}
+
+	if len(c.AskPass) > 0 {
+		env = append(env, "GIT_ASKPASS="+c.AskPass)
+	}
+
+	return append(env, envList(c.Env)...)
+}
+
+// RepositoryGitEnv returns the environment variables for git commands of a single repository.
+// All matching patterns are applied in alphabetical order.
+// A GIT_SSH_COMMAND of a repository replaces the global one, so it gets BatchMode, too.
+func (c *Config) RepositoryGitEnv(repoPath string) []string {
+	var env []string
+	for _, repoConfig := range c.matchingRepositories(repoPath) {
+		for _, variable := range envList(repoConfig.Env) {
+			sshCommand, found := strings.CutPrefix(variable, "GIT_SSH_COMMAND=")
+			if found && c.batchMode() {
+				variable = "GIT_SSH_COMMAND=" + batchModeSSHCommand(sshCommand)
+			}
+			env = append(env, variable)
+		}
+	}
+	return env
+}
+
+// batchMode returns if SSH should never prompt, which is the default
+func (c *Config) batchMode() bool {
+	return c.SSHBatchMode == nil || *c.SSHBatchMode
+}
+
+// batchModeSSHCommand adds BatchMode to the SSH command, unless it's already set
+func batchModeSSHCommand(sshCommand string) string {
+	if strings.Contains(sshCommand, "BatchMode") {
+		return sshCommand
+	}
+	return sshCommand + " -o BatchMode=yes"
+}
+
+// RepositoryTags returns the tags of a single repository, of all matching patterns in alphabetical order
+func (c *Config) RepositoryTags(repoPath string) []string {
+	var tags []string
+	seen := map[string]bool{}
+	for _, repoConfig := range c.matchingRepositories(repoPath) {
+		for _, tag := range repoConfig.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// RepositoryHooks returns the hooks of a single repository. The global hooks are overridden
+// by all matching patterns in alphabetical order, so the last set one wins.
+func (c *Config) RepositoryHooks(repoPath string) HooksConfig {
+	hooks := c.Hooks
+	for _, repoConfig := range c.matchingRepositories(repoPath) {
+		if len(repoConfig.Hooks.PostPull) > 0 {
+			hooks.PostPull = repoConfig.Hooks.PostPull
+		}
+		if len(repoConfig.Hooks.PrePush) > 0 {
+			hooks.PrePush = repoConfig.Hooks.PrePush
+		}
+		if len(repoConfig.Hooks.PostSync) > 0 {
+			hooks.PostSync = repoConfig.Hooks.PostSync
+		}
+	}
+	return hooks
+}
+
+// matchingRepositories returns the configs with a pattern matching the name of the repository,
+// in alphabetical order of the patterns
+func (c *Config) matchingRepositories(repoPath string) []RepositoryConfig {
+	name := path.Base(filepath.ToSlash(repoPath))
+
+	var patterns []string
+	for pattern := range c.Repositories {
+		matched, err := path.Match(pattern, name)
+		if err == nil && matched {
+			patterns = append(patterns, pattern)
+		}
+	}
+	sort.Strings(patterns)
+
+	configs := make([]RepositoryConfig, len(patterns))
+	for idx, pattern := range patterns {
+		configs[idx] = c.Repositories[pattern]
+	}
+	return configs
+}
+
+// envList converts the variables to a sorted "KEY=value" list
+func envList(vars map[string]string) []string {
+	env := make([]string, 0, len(vars))
+	for key, value := range vars {
+		env = append(env, key+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestGitEnv(t *testing.T) {
+	// Unset, but restored after the test
+	t.Setenv("GIT_SSH_COMMAND", "")
+	t.Setenv("GIT_SSH", "")
+	os.Unsetenv("GIT_SSH_COMMAND")
+	os.Unsetenv("GIT_SSH")
+
+	want := []string{"GIT_SSH_COMMAND=ssh -o BatchMode=yes"}
+	if got := (&Config{}).GitEnv(); !reflect.DeepEqual(got, want) {
+		t.Errorf("default GitEnv() = %v, want %v", got, want)
+	}
+
+	t.Setenv("GIT_SSH_COMMAND", "ssh -i custom")
+	if got := (&Config{}).GitEnv(); got != nil {
+		t.Errorf("GitEnv() with custom SSH command = %v, want none", got)
+	}
+	os.Unsetenv("GIT_SSH_COMMAND")
+
+	disabled := false
+	c := &Config{
+		AskPass:      "/usr/bin/askpass",
+		SSHBatchMode: &disabled,
+		Env: map[string]string{
+			"NO_PROXY":    "localhost",
+			"HTTPS_PROXY": "http://proxy:3128",
+		},
+	}
+
+	want = []string{"GIT_ASKPASS=/usr/bin/askpass", "HTTPS_PROXY=http://proxy:3128", "NO_PROXY=localhost"}
+	if got := c.GitEnv(); !reflect.DeepEqual(got, want) {
+		t.Errorf("GitEnv() = %v, want %v", got, want)
+	}
+}
+
+func TestRepositoryGitEnv(t *testing.T) {
+	c := &Config{
+		Repositories: map[string]RepositoryConfig{
+			"work-*":   {Env: map[string]string{"GIT_SSH_COMMAND": "ssh -i work"}},
+			"work-api": {Env: map[string]string{"GIT_SSH_COMMAND": "ssh -i api -o BatchMode=no"}},
+			"private":  {Env: map[string]string{"GIT_SSH_COMMAND": "ssh -i private", "NO_PROXY": "*"}},
+		},
+	}
+
+	tests := []struct {
+		repoPath string
+		want     []string
+	}{
+		{"/src/work-web", []string{"GIT_SSH_COMMAND=ssh -i work -o BatchMode=yes"}},
+		{"/src/work-api", []string{"GIT_SSH_COMMAND=ssh -i work -o BatchMode=yes", "GIT_SSH_COMMAND=ssh -i api -o BatchMode=no"}},
+		{"/src/private", []string{"GIT_SSH_COMMAND=ssh -i private -o BatchMode=yes", "NO_PROXY=*"}},
+		{"/src/other", nil},
+	}
+
+	for _, tt := range tests {
+		if got := c.RepositoryGitEnv(tt.repoPath); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("RepositoryGitEnv(%q) = %v, want %v", tt.repoPath, got, tt.want)
+		}
+	}
+
+	disabled := false
+	c.SSHBatchMode = &disabled
+
+	want := []string{"GIT_SSH_COMMAND=ssh -i private", "NO_PROXY=*"}
+	if got := c.RepositoryGitEnv("/src/private"); !reflect.DeepEqual(got, want) {
+		t.Errorf("RepositoryGitEnv() without BatchMode = %v, want %v", got, want)
+	}
+}
+
+func TestRepositoryTags(t *testing.T) {
+	c := &Config{
+		Repositories: map[string]RepositoryConfig{
+			"work-*":   {Tags: []string{"work"}},
+			"work-api": {Tags: []string{"backend", "work"}},
+		},
+	}
+
+	want := []string{"work", "backend"}
+	if got := c.RepositoryTags("/src/work-api"); !reflect.DeepEqual(got, want) {
+		t.Errorf("RepositoryTags() = %v, want %v", got, want)
+	}
+
+	if got := c.RepositoryTags("/src/private"); got != nil {
+		t.Errorf("RepositoryTags() = %v, want none", got)
+	}
+}
+
+func TestRepositoryHooks(t *testing.T) {
+	c := &Config{
+		Hooks: HooksConfig{PostPull: "make", PostSync: "notify"},
+		Repositories: map[string]RepositoryConfig{
+			"web-*":   {Hooks: HooksConfig{PostPull: "npm ci"}},
+			"web-app": {Hooks: HooksConfig{PrePush: "npm test"}},
+		},
+	}
+
+	want := HooksConfig{PostPull: "npm ci", PrePush: "npm test", PostSync: "notify"}
+	if got := c.RepositoryHooks("/src/web-app"); got != want {
+		t.Errorf("RepositoryHooks() = %+v, want %+v", got, want)
+	}
+
+	if got := c.RepositoryHooks("/src/api"); got != c.Hooks {
+		t.Errorf("RepositoryHooks() = %+v, want the global hooks", got)
+	}
+}
@@ -0,0 +1,218 @@
+package git
+
+import "time"
+
+// Backend runs the git operations a repository depends on.
+// All operations work on the repository at the provided path.
+type Backend interface {
+	// LocalBranch returns the local branch name of the current HEAD
+	LocalBranch(repoPath string) (string, error)
+
+	// UpstreamBranch returns the name of the upstream branch, e.g. "origin/main"
+	UpstreamBranch(repoPath string) (string, error)
+
+	// Status counts the changed and unversioned files of the working tree
+	Status(repoPath string) (StatusCounts, error)
+
+	// Fetch fetches the specified remote, and passes its progress to progress, if not nil
+	Fetch(repoPath string, remote string, progress func(FetchProgress)) error
+
+	// Incoming counts the incoming commits (head vs upstream)
+	Incoming(repoPath string, branch string) (int, error)
+
+	// Outgoing counts the outgoing commits (push vs head)
+	Outgoing(repoPath string, branch string) (int, error)
+
+	// RevParse returns the commit hash of the revision
+	RevParse(repoPath string, revision string) (string, error)
+
+	// CommitDate returns the committer date of the revision
+	CommitDate(repoPath string, revision string) (time.Time, error)
+
+	// RemoteURL returns the fetch URL of the remote
+	RemoteURL(repoPath string, remote string) (string, error)
+
+	// Rebase tries to rebase the current working tree with the upstream
+	Rebase(repoPath string) error
+
+	// Push pushes the repository to the remote
+	Push(repoPath string) error
+
+	// StashSave stashes the current working tree
+	StashSave(repoPath string) error
+
+	// StashPop pops the last stash
+	StashPop(repoPath string) error
+
+	// HasBranch checks if a local or remote-tracking branch with the name exists
+	HasBranch(repoPath string, branch string) (bool, error)
+
+	// DefaultBranch returns the default branch of the remote, e.g. "origin/main"
+	DefaultBranch(repoPath string, remote string) (string, error)
+
+	// Checkout switches to the branch, creating a tracking branch if only a remote one exists
+	Checkout(repoPath string, branch string) error
+
+	// CreateBranch creates a new branch at the start point and switches to it, an empty one is HEAD
+	CreateBranch(repoPath string, branch string, startPoint string) error
+
+	// FetchPrune fetches the remote and removes remote-tracking branches that no longer exist
+	FetchPrune(repoPath string, remote string) error
+
+	// LocalBranches returns all local branches
+	LocalBranches(repoPath string) ([]LocalBranchInfo, error)
+
+	// MergedBranches returns the local branches that are fully merged into the target
+	MergedBranches(repoPath string, target string) ([]string, error)
+
+	// IsSquashMerged checks if the changes of the branch are already contained in the target
+	IsSquashMerged(repoPath string, branch string, target string) (bool, error)
+
+	// DeleteBranch force-deletes a local branch
+	DeleteBranch(repoPath string, branch string) error
+
+	// Log returns the commits reachable from the revisions, optionally limited by date and author
+	Log(repoPath string, since string, author string, revisions ...string) ([]Commit, error)
+
+	// Grep searches the tracked files for lines matching the pattern, optionally limited to the pathspecs
+	Grep(repoPath string, pattern string, ignoreCase bool, pathspecs ...string) ([]GrepMatch, error)
+
+	// Reflog returns the commit hashes of the reflog of HEAD, newest first
+	Reflog(repoPath string) ([]string, error)
+
+	// ResetKeep resets the current branch to the commit, but keeps local changes
+	ResetKeep(repoPath string, commit string) error
+
+	// LastFetch returns when the repository was fetched the last time, zero if never
+	LastFetch(repoPath string) (time.Time, error)
+
+	// StashCount counts the stash entries
+	StashCount(repoPath string) (int, error)
+
+	// CommonDir returns the absolute path of the git dir shared by all worktrees
+	CommonDir(repoPath string) (string, error)
+}
+
+// ExecBackend runs all operations with the git executable
+type ExecBackend struct{}
+
+// NewExecBackend returns a Backend running the git executable
+func NewExecBackend() *ExecBackend {
+	return &ExecBackend{}
+}
+
+func (ExecBackend) LocalBranch(repoPath string) (string, error) {
+	return LocalBranch(repoPath)
+}
+
+func (ExecBackend) UpstreamBranch(repoPath string) (string, error) {
+	return UpstreamBranch(repoPath)
+}
+
+func (ExecBackend) Status(repoPath string) (StatusCounts, error) {
+	return Status(repoPath)
+}
+
+func (ExecBackend) Fetch(repoPath string, remote string, progress func(FetchProgress)) error {
+	return Fetch(repoPath, remote, progress)
+}
+
+func (ExecBackend) Incoming(repoPath string, branch string) (int, error) {
+	return Incoming(repoPath, branch)
+}
+
+func (ExecBackend) Outgoing(repoPath string, branch string) (int, error) {
+	return Outgoing(repoPath, branch)
+}
+
+func (ExecBackend) RevParse(repoPath string, revision string) (string, error) {
+	return RevParse(repoPath, revision)
+}
+
+func (ExecBackend) CommitDate(repoPath string, revision string) (time.Time, error) {
+	return CommitDate(repoPath, revision)
+}
+
+func (ExecBackend) RemoteURL(repoPath string, remote string) (string, error) {
+	return RemoteURL(repoPath, remote)
+}
+
+func (ExecBackend) Rebase(repoPath string) error {
+	return Rebase(repoPath)
+}
+
+func (ExecBackend) Push(repoPath string) error {
+	return Push(repoPath)
+}
+
+func (ExecBackend) StashSave(repoPath string) error {
+	return StashSave(repoPath)
+}
+
+func (ExecBackend) StashPop(repoPath string) error {
+	return StashPop(repoPath)
+}
+
+func (ExecBackend) HasBranch(repoPath string, branch string) (bool, error) {
+	return HasBranch(repoPath, branch)
+}
+
+func (ExecBackend) DefaultBranch(repoPath string, remote string) (string, error) {
+	return DefaultBranch(repoPath, remote)
+}
+
+func (ExecBackend) Checkout(repoPath string, branch string) error {
+	return Checkout(repoPath, branch)
+}
+
+func (ExecBackend) CreateBranch(repoPath string, branch string, startPoint string) error {
+	return CreateBranch(repoPath, branch, startPoint)
+}
+
+func (ExecBackend) FetchPrune(repoPath string, remote string) error {
+	return FetchPrune(repoPath, remote)
+}
+
+func (ExecBackend) LocalBranches(repoPath string) ([]LocalBranchInfo, error) {
+	return LocalBranches(repoPath)
+}
+
+func (ExecBackend) MergedBranches(repoPath string, target string) ([]string, error) {
+	return MergedBranches(repoPath, target)
+}
+
+func (ExecBackend) IsSquashMerged(repoPath string, branch string, target string) (bool, error) {
+	return IsSquashMerged(repoPath, branch, target)
+}
+
+func (ExecBackend) DeleteBranch(repoPath string, branch string) error {
+	return DeleteBranch(repoPath, branch)
+}
+
+func (ExecBackend) Log(repoPath string, since string, author string, revisions ...string) ([]Commit, error) {
+	return Log(repoPath, since, author, revisions...)
+}
+
+func (ExecBackend) Grep(repoPath string, pattern string, ignoreCase bool, pathspecs ...string) ([]GrepMatch, error) {
+	return Grep(repoPath, pattern, ignoreCase, pathspecs...)
+}
+
+func (ExecBackend) Reflog(repoPath string) ([]string, error) {
+	return Reflog(repoPath)
+}
+
+func (ExecBackend) ResetKeep(repoPath string, commit string) error {
+	return ResetKeep(repoPath, commit)
+}
+
+func (ExecBackend) LastFetch(repoPath string) (time.Time, error) {
+	return LastFetch(repoPath)
+}
+
+func (ExecBackend) StashCount(repoPath string) (int, error) {
+	return StashCount(repoPath)
+}
+
+func (ExecBackend) CommonDir(repoPath string) (string, error) {
+	return CommonDir(repoPath)
+}
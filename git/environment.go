@@ -0,0 +1,38 @@
+package git
+
+import "os"
+
+// Environment configures additional environment variables of the git executable
+type Environment struct {
+	// Vars are added to every command, as "KEY=value"
+	Vars []string
+
+	// ForRepository returns variables added to every command of a single repository,
+	// e.g. a different GIT_SSH_COMMAND per repository. Might be nil.
+	ForRepository func(repoPath string) []string
+}
+
+var environment Environment
+
+// SetEnvironment configures the environment of all following commands.
+// It's not synchronized, so it must be set before running any commands.
+func SetEnvironment(e Environment) {
+	environment = e
+}
+
+// commandEnv builds the environment of a single command. Later variables override
+// earlier ones, so the repository-specific ones have the highest priority.
+func commandEnv(repoPath string) []string {
+	env := os.Environ()
+
+	// Disable terminal prompting so it fails if credentials are needed etc.
+	env = append(env, "GIT_TERMINAL_PROMPT=0")
+
+	env = append(env, environment.Vars...)
+
+	if environment.ForRepository != nil {
+		env = append(env, environment.ForRepository(repoPath)...)
+	}
+
+	return env
+}
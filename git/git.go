@@ -1,13 +1,19 @@
 package git
 
 import (
+	"bufio"
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // IsAvailable checks if git executable is available in the path
@@ -16,35 +22,46 @@ func IsAvailable() error {
 	return err
 }
 
-// IsRepo tries to determinate if the a path is repo by checking for a '.git' folder
+// IsRepo tries to determinate if the a path is repo by checking for a '.git' folder,
+// or a '.git' file pointing to the git dir of a worktree
 func IsRepo(basePath string) bool {
 	gitPath := path.Join(basePath, ".git")
 	stat, err := os.Stat(gitPath)
-	return err == nil && stat.IsDir()
+	if err != nil {
+		return false
+	}
+
+	if stat.IsDir() {
+		return true
+	}
+
+	content, err := os.ReadFile(gitPath)
+	return err == nil && strings.HasPrefix(string(content), "gitdir: ")
 }
 
 func git(repoPath string, args ...string) (bytes.Buffer, error) {
-	// Disable terminal prompting so it fails if credentials are needed etc.
-	err := os.Setenv("GIT_TERMINAL_PROMPT", "0")
-	if err != nil {
-		return bytes.Buffer{}, err
-	}
+	var errBuffer bytes.Buffer
+	return runGit(repoPath, &errBuffer, &errBuffer, args...)
+}
 
+// runGit runs git like git, but StdErr is written to the provided Writer,
+// which must write at least everything relevant for errors to the errBuffer
+func runGit(repoPath string, stdErr io.Writer, errBuffer *bytes.Buffer, args ...string) (bytes.Buffer, error) {
 	// Combine args and build command
 	args = append([]string{"-C", repoPath}, args...)
 	cmd := exec.Command("git", args...)
+	cmd.Env = commandEnv(repoPath)
 
 	// Attach buffers, a function might need both so just grab'em
 	var outBuffer bytes.Buffer
-	var errBuffer bytes.Buffer
 	cmd.Stdout = &outBuffer
-	cmd.Stderr = &errBuffer
+	cmd.Stderr = stdErr
 
 	// Run command, but don't handle errors here, this is just a helper function
-	err = cmd.Run()
+	err := cmd.Run()
 
 	if err != nil {
-		err = wrapError(err, errBuffer)
+		err = wrapError(err, *errBuffer)
 	}
 	return outBuffer, err
 }
@@ -104,15 +121,62 @@ func Outgoing(repoPath string, branch string) (int, error) {
 	return len(commits), err
 }
 
-// Fetch fetches the specified remote
-func Fetch(repoPath string, remote string) error {
-	_, err := git(repoPath, "fetch", remote)
+// Fetch fetches the specified remote. The progress reported by git is passed to progress, if not nil.
+func Fetch(repoPath string, remote string, progress func(FetchProgress)) error {
+	if progress == nil {
+		_, err := git(repoPath, "fetch", remote)
+		return err
+	}
+
+	// git only reports progress to terminals, unless requested
+	var errBuffer bytes.Buffer
+	stdErr := &progressWriter{progress: progress, errors: &errBuffer}
+
+	_, err := runGit(repoPath, stdErr, &errBuffer, "fetch", "--progress", remote)
+
+	// Only the last progress might be left without a line break
+	stdErr.flush()
 	return err
 }
 
-// Status returns a parseable (--porcelain) status
-func Status(repoPath string) (bytes.Buffer, error) {
-	return git(repoPath, "status", "--porcelain")
+// StatusCounts are the changed and unversioned files of a working tree
+type StatusCounts struct {
+	Changes     int
+	Unversioned int
+}
+
+// Status counts the changed and unversioned files of the working tree, based on a parseable (--porcelain) status.
+// The index isn't refreshed, so concurrent git operations aren't blocked by its lock.
+func Status(repoPath string) (StatusCounts, error) {
+	stdOut, err := git(repoPath, "--no-optional-locks", "status", "--porcelain")
+	if err != nil {
+		return StatusCounts{}, err
+	}
+
+	var counts StatusCounts
+
+	scanner := bufio.NewScanner(&stdOut)
+
+	for scanner.Scan() {
+		row := scanner.Text()
+		if len(row) < 3 {
+			continue
+		}
+
+		status := strings.TrimSpace(row[0:3])
+		if len(status) == 0 {
+			continue
+		}
+
+		switch status[0] {
+		case 'M', 'T', 'A', 'D', 'R', 'C', 'U':
+			counts.Changes++
+		case '?':
+			counts.Unversioned++
+		}
+	}
+
+	return counts, nil
 }
 
 // Rebase tries to rebase the current working tree with the upstream
@@ -138,3 +202,421 @@ func StashPop(repoPath string) error {
 	_, err := git(repoPath, "stash", "pop")
 	return err
 }
+
+// HasBranch checks if a local branch, or a remote-tracking branch of any remote, with the given name exists
+func HasBranch(repoPath string, branch string) (bool, error) {
+	stdOut, err := git(repoPath, "for-each-ref", "--format=%(refname)", "refs/heads", "refs/remotes")
+	if err != nil {
+		return false, err
+	}
+
+	refs := strings.FieldsFunc(stdOut.String(), func(r rune) bool {
+		return r == '\n'
+	})
+
+	for _, ref := range refs {
+		if ref == "refs/heads/"+branch {
+			return true, nil
+		}
+
+		// Remote-tracking branches are prefixed with "refs/remotes/<remote>/"
+		parts := strings.SplitN(ref, "/", 4)
+		if len(parts) == 4 && parts[1] == "remotes" && parts[3] == branch {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// DefaultBranch returns the default branch of the remote, e.g. "origin/main"
+func DefaultBranch(repoPath string, remote string) (string, error) {
+	stdOut, err := git(repoPath, "symbolic-ref", "--short", fmt.Sprintf("refs/remotes/%s/HEAD", remote))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(stdOut.String()), nil
+}
+
+// Checkout switches to the branch, creating a tracking branch if only a remote one exists
+func Checkout(repoPath string, branch string) error {
+	_, err := git(repoPath, "checkout", branch)
+	return err
+}
+
+// CreateBranch creates a new branch without tracking information and switches to it.
+// An empty start point uses the current HEAD.
+func CreateBranch(repoPath string, branch string, startPoint string) error {
+	args := []string{"checkout", "--no-track", "-b", branch}
+	if len(startPoint) > 0 {
+		args = append(args, startPoint)
+	}
+
+	_, err := git(repoPath, args...)
+	return err
+}
+
+// FetchPrune fetches the specified remote and removes remote-tracking branches that no longer exist
+func FetchPrune(repoPath string, remote string) error {
+	_, err := git(repoPath, "fetch", "--prune", remote)
+	return err
+}
+
+// LocalBranchInfo represents a local branch and the tracking state of its upstream
+type LocalBranchInfo struct {
+	Name         string
+	UpstreamGone bool
+}
+
+// LocalBranches returns all local branches
+func LocalBranches(repoPath string) ([]LocalBranchInfo, error) {
+	stdOut, err := git(repoPath, "for-each-ref", "--format=%(refname:short)%00%(upstream:track)", "refs/heads")
+	if err != nil {
+		return []LocalBranchInfo{}, err
+	}
+
+	var branches []LocalBranchInfo
+	for _, row := range strings.Split(stdOut.String(), "\n") {
+		parts := strings.SplitN(row, "\x00", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		branches = append(branches, LocalBranchInfo{
+			Name:         parts[0],
+			UpstreamGone: parts[1] == "[gone]",
+		})
+	}
+
+	return branches, nil
+}
+
+// MergedBranches returns the local branches that are fully merged into the target
+func MergedBranches(repoPath string, target string) ([]string, error) {
+	stdOut, err := git(repoPath, "branch", "--format=%(refname:short)", "--merged", target)
+	if err != nil {
+		return []string{}, err
+	}
+
+	branches := strings.FieldsFunc(stdOut.String(), func(r rune) bool {
+		return r == '\n'
+	})
+
+	return branches, nil
+}
+
+// IsSquashMerged checks if the changes of the branch are already contained in the target, e.g. after a squash merge.
+// The branch is squashed into a temporary commit on top of the merge base, which is compared by patch-id.
+func IsSquashMerged(repoPath string, branch string, target string) (bool, error) {
+	stdOut, err := git(repoPath, "merge-base", target, branch)
+	if err != nil {
+		return false, err
+	}
+	mergeBase := strings.TrimSpace(stdOut.String())
+
+	// The temporary commit is never referenced and will be garbage collected,
+	// so a placeholder identity is good enough
+	stdOut, err = git(repoPath,
+		"-c", "user.name=tortuga",
+		"-c", "user.email=tortuga@localhost",
+		"commit-tree", branch+"^{tree}", "-p", mergeBase, "-m", "squash")
+	if err != nil {
+		return false, err
+	}
+	squashCommit := strings.TrimSpace(stdOut.String())
+
+	stdOut, err = git(repoPath, "cherry", target, squashCommit)
+	if err != nil {
+		return false, err
+	}
+
+	return strings.HasPrefix(stdOut.String(), "-"), nil
+}
+
+// DeleteBranch force-deletes a local branch
+func DeleteBranch(repoPath string, branch string) error {
+	_, err := git(repoPath, "branch", "-D", branch)
+	return err
+}
+
+// Commit represents a single commit of the history
+type Commit struct {
+	Hash    string
+	Author  string
+	Date    time.Time
+	Subject string
+}
+
+// Log returns the commits reachable from the revisions, optionally limited by date and author.
+// Both since and author are passed verbatim to git, so all their formats are supported.
+func Log(repoPath string, since string, author string, revisions ...string) ([]Commit, error) {
+	args := []string{"log", "--format=%H%x00%an%x00%aI%x00%s"}
+	if len(since) > 0 {
+		args = append(args, "--since="+since)
+	}
+	if len(author) > 0 {
+		args = append(args, "--author="+author)
+	}
+	args = append(args, revisions...)
+	args = append(args, "--")
+
+	stdOut, err := git(repoPath, args...)
+	if err != nil {
+		return []Commit{}, err
+	}
+
+	var commits []Commit
+	for _, row := range strings.Split(stdOut.String(), "\n") {
+		parts := strings.SplitN(row, "\x00", 4)
+		if len(parts) != 4 {
+			continue
+		}
+
+		date, err := time.Parse(time.RFC3339, parts[2])
+		if err != nil {
+			return []Commit{}, err
+		}
+
+		commits = append(commits, Commit{
+			Hash:    parts[0],
+			Author:  parts[1],
+			Date:    date,
+			Subject: parts[3],
+		})
+	}
+
+	return commits, nil
+}
+
+// GrepMatch represents a single line matching a grep pattern
+type GrepMatch struct {
+	Path string
+	Line int
+	Text string
+}
+
+// Grep searches the tracked files of the working tree for lines matching the pattern,
+// optionally limited to the pathspecs. Binary files are ignored.
+func Grep(repoPath string, pattern string, ignoreCase bool, pathspecs ...string) ([]GrepMatch, error) {
+	args := []string{"grep", "-n", "-z", "-I"}
+	if ignoreCase {
+		args = append(args, "-i")
+	}
+	args = append(args, "-e", pattern, "--")
+	args = append(args, pathspecs...)
+
+	stdOut, err := git(repoPath, args...)
+	if err != nil {
+		// git grep fails without any output if there are no matches
+		var ge *ExternalError
+		if errors.As(err, &ge) && len(ge.StdErr) == 0 {
+			return []GrepMatch{}, nil
+		}
+		return []GrepMatch{}, err
+	}
+
+	var matches []GrepMatch
+	for _, row := range strings.Split(stdOut.String(), "\n") {
+		parts := strings.SplitN(row, "\x00", 3)
+		if len(parts) != 3 {
+			continue
+		}
+
+		line, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return []GrepMatch{}, err
+		}
+
+		matches = append(matches, GrepMatch{
+			Path: parts[0],
+			Line: line,
+			Text: parts[2],
+		})
+	}
+
+	return matches, nil
+}
+
+// RevParse returns the commit hash of the revision
+func RevParse(repoPath string, revision string) (string, error) {
+	stdOut, err := git(repoPath, "rev-parse", "--verify", revision)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(stdOut.String()), nil
+}
+
+// CommitDate returns the committer date of the revision
+func CommitDate(repoPath string, revision string) (time.Time, error) {
+	stdOut, err := git(repoPath, "log", "-1", "--format=%cI", revision, "--")
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Parse(time.RFC3339, strings.TrimSpace(stdOut.String()))
+}
+
+// RemoteURL returns the fetch URL of the remote
+func RemoteURL(repoPath string, remote string) (string, error) {
+	stdOut, err := git(repoPath, "remote", "get-url", remote)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(stdOut.String()), nil
+}
+
+// RemoteHost returns the host of a remote URL, e.g. "github.com" for both "https://github.com/user/repo.git"
+// and the scp-like "git@github.com:user/repo.git". Local paths have no host.
+func RemoteHost(remoteURL string) string {
+	if strings.Contains(remoteURL, "://") {
+		u, err := url.Parse(remoteURL)
+		if err != nil {
+			return ""
+		}
+		return u.Hostname()
+	}
+
+	// The scp-like syntax is only recognized if there are no slashes before the first colon
+	colon := strings.Index(remoteURL, ":")
+	slash := strings.Index(remoteURL, "/")
+	if colon < 0 || (slash >= 0 && slash < colon) {
+		return ""
+	}
+
+	host := remoteURL[:colon]
+	if at := strings.LastIndex(host, "@"); at >= 0 {
+		host = host[at+1:]
+	}
+
+	// A single letter is a Windows drive
+	if len(host) < 2 {
+		return ""
+	}
+
+	return host
+}
+
+// LastFetch returns when the repository was fetched the last time, zero if never.
+// Worktrees might rely on fetches of the main working tree, so both are considered.
+func LastFetch(repoPath string) (time.Time, error) {
+	stdOut, err := git(repoPath, "rev-parse", "--git-path", "FETCH_HEAD", "--git-common-dir")
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdOut.String()), "\n")
+	if len(lines) != 2 {
+		return time.Time{}, fmt.Errorf("unexpected output of rev-parse: '%s'", stdOut.String())
+	}
+
+	var lastFetch time.Time
+	for _, fetchHead := range []string{lines[0], filepath.Join(lines[1], "FETCH_HEAD")} {
+		if !filepath.IsAbs(fetchHead) {
+			fetchHead = filepath.Join(repoPath, fetchHead)
+		}
+
+		stat, err := os.Stat(fetchHead)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if stat.ModTime().After(lastFetch) {
+			lastFetch = stat.ModTime()
+		}
+	}
+
+	return lastFetch, nil
+}
+
+// StashCount counts the stash entries
+func StashCount(repoPath string) (int, error) {
+	stdOut, err := git(repoPath, "stash", "list")
+	if err != nil {
+		return 0, err
+	}
+
+	return strings.Count(stdOut.String(), "\n"), nil
+}
+
+// Reflog returns the commit hashes of the reflog of HEAD, newest first
+func Reflog(repoPath string) ([]string, error) {
+	stdOut, err := git(repoPath, "reflog", "--format=%H", "HEAD")
+	if err != nil {
+		return []string{}, err
+	}
+
+	commits := strings.FieldsFunc(stdOut.String(), func(r rune) bool {
+		return r == '\n'
+	})
+
+	return commits, nil
+}
+
+// ResetKeep resets the current branch to the commit, but keeps local changes.
+// Fails if a local change would be overwritten.
+func ResetKeep(repoPath string, commit string) error {
+	_, err := git(repoPath, "reset", "--keep", commit)
+	return err
+}
+
+// GitDir returns the absolute path of the git dir of a working tree, which is its own one for worktrees
+func GitDir(repoPath string) (string, error) {
+	stdOut, err := git(repoPath, "rev-parse", "--git-dir")
+	if err != nil {
+		return "", err
+	}
+
+	gitDir := strings.TrimSpace(stdOut.String())
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(repoPath, gitDir)
+	}
+
+	return filepath.Abs(gitDir)
+}
+
+// CommonDir returns the absolute path of the git dir shared by all worktrees of a repository
+func CommonDir(repoPath string) (string, error) {
+	stdOut, err := git(repoPath, "rev-parse", "--git-common-dir")
+	if err != nil {
+		return "", err
+	}
+
+	commonDir := strings.TrimSpace(stdOut.String())
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(repoPath, commonDir)
+	}
+
+	return filepath.Abs(commonDir)
+}
+
+// ObjectStore returns the absolute path of the object directory a repository borrows its objects from
+// via alternates, e.g. after cloning with '--reference', or its own object directory
+func ObjectStore(commonDir string) string {
+	objectsDir := filepath.Join(commonDir, "objects")
+
+	content, err := os.ReadFile(filepath.Join(objectsDir, "info", "alternates"))
+	if err != nil {
+		return objectsDir
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Relative alternates are relative to the object directory
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(objectsDir, line)
+		}
+		return filepath.Clean(line)
+	}
+
+	return objectsDir
+}
@@ -2,6 +2,7 @@ package git
 
 import (
 	"bytes"
+	"errors"
 	"strings"
 )
 
@@ -25,14 +26,48 @@ func isNoUpstreamError(ge *ExternalError) bool {
 	return strings.HasPrefix(ge.StdErr, "fatal: no upstream")
 }
 
+// transientErrors are stderr fragments of network failures that might succeed if tried again
+var transientErrors = []string{
+	"Connection reset",
+	"Connection timed out",
+	"Operation timed out",
+	"early EOF",
+	"unexpected disconnect",
+	"RPC failed",
+	"The requested URL returned error: 5",
+	"Could not resolve host",
+	"Connection closed",
+}
+
+func isTransientError(ge *ExternalError) bool {
+	for _, fragment := range transientErrors {
+		if strings.Contains(ge.StdErr, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTransientError checks if the error is a network failure that might succeed if tried again,
+// e.g. a connection reset, a timeout, or an HTTP 5xx
+func IsTransientError(err error) bool {
+	var ge *ExternalError
+	return errors.As(err, &ge) && isTransientError(ge)
+}
+
 func wrapError(err error, stdErr bytes.Buffer) *ExternalError {
 	if err == nil {
 		return nil
 	}
 
+	return NewExternalError(err, stdErr.String())
+}
+
+// NewExternalError creates an ExternalError classified by the stderr output of git
+func NewExternalError(cause error, stdErr string) *ExternalError {
 	ge := &ExternalError{
-		Cause:  err,
-		StdErr: stdErr.String(),
+		Cause:  cause,
+		StdErr: stdErr,
 	}
 
 	switch {
@@ -40,6 +75,8 @@ func wrapError(err error, stdErr bytes.Buffer) *ExternalError {
 		ge.message = "auth error"
 	case isNoUpstreamError(ge):
 		ge.message = "no upstream"
+	case isTransientError(ge):
+		ge.message = "network error"
 	default:
 		ge.message = "error"
 	}
@@ -0,0 +1,33 @@
+package git
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewExternalError(t *testing.T) {
+	tests := []struct {
+		stdErr    string
+		message   string
+		transient bool
+	}{
+		{"fatal: could not read Username for 'https://example.com': terminal prompts disabled", "auth error", false},
+		{"fatal: no upstream configured for branch 'main'", "no upstream", false},
+		{"fatal: unable to access 'https://example.com/': Failed to connect to example.com port 443: Connection timed out", "network error", true},
+		{"error: RPC failed; curl 56 OpenSSL SSL_read: Connection reset by peer\nfatal: early EOF", "network error", true},
+		{"fatal: unable to access 'https://example.com/': The requested URL returned error: 502", "network error", true},
+		{"git@example.com: Permission denied (publickey).\nfatal: Could not read from remote repository.", "error", false},
+		{" ! [rejected]        main -> main (fetch first)", "error", false},
+	}
+
+	for _, tt := range tests {
+		ge := NewExternalError(errors.New("exit status 128"), tt.stdErr)
+
+		if ge.Error() != tt.message {
+			t.Errorf("message of %q = %q, want %q", tt.stdErr, ge.Error(), tt.message)
+		}
+		if IsTransientError(ge) != tt.transient {
+			t.Errorf("IsTransientError(%q) = %t, want %t", tt.stdErr, !tt.transient, tt.transient)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package git
+
+import "testing"
+
+func TestRemoteHost(t *testing.T) {
+	tests := []struct {
+		remoteURL string
+		want      string
+	}{
+		{"https://github.com/benweidig/tortuga.git", "github.com"},
+		{"https://user@gitlab.example.com:8443/group/repo.git", "gitlab.example.com"},
+		{"ssh://git@github.com:22/benweidig/tortuga.git", "github.com"},
+		{"git@github.com:benweidig/tortuga.git", "github.com"},
+		{"github.com:benweidig/tortuga.git", "github.com"},
+		{"file:///srv/git/repo.git", ""},
+		{"/srv/git/repo.git", ""},
+		{"../repo.git", ""},
+		{"./dir:with-colon/repo.git", ""},
+		{`C:\git\repo.git`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.remoteURL, func(t *testing.T) {
+			if got := RemoteHost(tt.remoteURL); got != tt.want {
+				t.Errorf("host = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,311 @@
+package gittest
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/benweidig/tortuga/git"
+)
+
+// Operations of a git.Backend that can be scripted to fail
+const (
+	OpLocalBranch    = "local-branch"
+	OpUpstreamBranch = "upstream-branch"
+	OpStatus         = "status"
+	OpFetch          = "fetch"
+	OpIncoming       = "incoming"
+	OpOutgoing       = "outgoing"
+	OpRevParse       = "rev-parse"
+	OpCommitDate     = "commit-date"
+	OpRemoteURL      = "remote-url"
+	OpRebase         = "rebase"
+	OpPush           = "push"
+	OpStashSave      = "stash-save"
+	OpStashPop       = "stash-pop"
+	OpHasBranch      = "has-branch"
+	OpDefaultBranch  = "default-branch"
+	OpCheckout       = "checkout"
+	OpCreateBranch   = "create-branch"
+	OpFetchPrune     = "fetch-prune"
+	OpLocalBranches  = "local-branches"
+	OpMergedBranches = "merged-branches"
+	OpIsSquashMerged = "is-squash-merged"
+	OpDeleteBranch   = "delete-branch"
+	OpLog            = "log"
+	OpGrep           = "grep"
+	OpReflog         = "reflog"
+	OpResetKeep      = "reset-keep"
+	OpLastFetch      = "last-fetch"
+	OpStashCount     = "stash-count"
+	OpCommonDir      = "common-dir"
+)
+
+// AuthError returns the error git fails with if credentials are needed
+func AuthError() error {
+	return git.NewExternalError(errors.New("exit status 128"), "fatal: could not read Username for 'https://example.com': terminal prompts disabled\n")
+}
+
+// TimeoutError returns the error git fails with if the remote can't be reached in time
+func TimeoutError() error {
+	return git.NewExternalError(errors.New("exit status 128"), "fatal: unable to access 'https://example.com/repo.git/': Failed to connect to example.com port 443: Connection timed out\n")
+}
+
+// PushRejectedError returns the error git fails with if the remote has diverged
+func PushRejectedError() error {
+	return git.NewExternalError(errors.New("exit status 1"), " ! [rejected]        main -> main (fetch first)\nerror: failed to push some refs to 'https://example.com/repo.git'\n")
+}
+
+// FakeBackend delegates to another git.Backend, but fails the scripted operations.
+// All calls are recorded.
+type FakeBackend struct {
+	Delegate git.Backend
+
+	mtx      sync.Mutex
+	failures map[string][]error
+	calls    []string
+}
+
+// NewFakeBackend returns a FakeBackend delegating to the git executable
+func NewFakeBackend() *FakeBackend {
+	return &FakeBackend{
+		Delegate: git.NewExecBackend(),
+		failures: map[string][]error{},
+	}
+}
+
+// Fail lets the next calls of the operation fail with the errors, one call per error.
+// Afterwards, the operation is delegated again.
+func (b *FakeBackend) Fail(op string, errs ...error) *FakeBackend {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.failures[op] = append(b.failures[op], errs...)
+	return b
+}
+
+// Calls returns the operations called so far, in order
+func (b *FakeBackend) Calls() []string {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	return append([]string(nil), b.calls...)
+}
+
+// call records the operation and returns its scripted error, if any
+func (b *FakeBackend) call(op string) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.calls = append(b.calls, op)
+
+	errs := b.failures[op]
+	if len(errs) == 0 {
+		return nil
+	}
+	b.failures[op] = errs[1:]
+	return errs[0]
+}
+
+func (b *FakeBackend) LocalBranch(repoPath string) (string, error) {
+	if err := b.call(OpLocalBranch); err != nil {
+		return "", err
+	}
+	return b.Delegate.LocalBranch(repoPath)
+}
+
+func (b *FakeBackend) UpstreamBranch(repoPath string) (string, error) {
+	if err := b.call(OpUpstreamBranch); err != nil {
+		return "", err
+	}
+	return b.Delegate.UpstreamBranch(repoPath)
+}
+
+func (b *FakeBackend) Status(repoPath string) (git.StatusCounts, error) {
+	if err := b.call(OpStatus); err != nil {
+		return git.StatusCounts{}, err
+	}
+	return b.Delegate.Status(repoPath)
+}
+
+func (b *FakeBackend) Fetch(repoPath string, remote string, progress func(git.FetchProgress)) error {
+	if err := b.call(OpFetch); err != nil {
+		return err
+	}
+	return b.Delegate.Fetch(repoPath, remote, progress)
+}
+
+func (b *FakeBackend) Incoming(repoPath string, branch string) (int, error) {
+	if err := b.call(OpIncoming); err != nil {
+		return 0, err
+	}
+	return b.Delegate.Incoming(repoPath, branch)
+}
+
+func (b *FakeBackend) Outgoing(repoPath string, branch string) (int, error) {
+	if err := b.call(OpOutgoing); err != nil {
+		return 0, err
+	}
+	return b.Delegate.Outgoing(repoPath, branch)
+}
+
+func (b *FakeBackend) RevParse(repoPath string, revision string) (string, error) {
+	if err := b.call(OpRevParse); err != nil {
+		return "", err
+	}
+	return b.Delegate.RevParse(repoPath, revision)
+}
+
+func (b *FakeBackend) CommitDate(repoPath string, revision string) (time.Time, error) {
+	if err := b.call(OpCommitDate); err != nil {
+		return time.Time{}, err
+	}
+	return b.Delegate.CommitDate(repoPath, revision)
+}
+
+func (b *FakeBackend) RemoteURL(repoPath string, remote string) (string, error) {
+	if err := b.call(OpRemoteURL); err != nil {
+		return "", err
+	}
+	return b.Delegate.RemoteURL(repoPath, remote)
+}
+
+func (b *FakeBackend) Rebase(repoPath string) error {
+	if err := b.call(OpRebase); err != nil {
+		return err
+	}
+	return b.Delegate.Rebase(repoPath)
+}
+
+func (b *FakeBackend) Push(repoPath string) error {
+	if err := b.call(OpPush); err != nil {
+		return err
+	}
+	return b.Delegate.Push(repoPath)
+}
+
+func (b *FakeBackend) StashSave(repoPath string) error {
+	if err := b.call(OpStashSave); err != nil {
+		return err
+	}
+	return b.Delegate.StashSave(repoPath)
+}
+
+func (b *FakeBackend) StashPop(repoPath string) error {
+	if err := b.call(OpStashPop); err != nil {
+		return err
+	}
+	return b.Delegate.StashPop(repoPath)
+}
+
+func (b *FakeBackend) HasBranch(repoPath string, branch string) (bool, error) {
+	if err := b.call(OpHasBranch); err != nil {
+		return false, err
+	}
+	return b.Delegate.HasBranch(repoPath, branch)
+}
+
+func (b *FakeBackend) DefaultBranch(repoPath string, remote string) (string, error) {
+	if err := b.call(OpDefaultBranch); err != nil {
+		return "", err
+	}
+	return b.Delegate.DefaultBranch(repoPath, remote)
+}
+
+func (b *FakeBackend) Checkout(repoPath string, branch string) error {
+	if err := b.call(OpCheckout); err != nil {
+		return err
+	}
+	return b.Delegate.Checkout(repoPath, branch)
+}
+
+func (b *FakeBackend) CreateBranch(repoPath string, branch string, startPoint string) error {
+	if err := b.call(OpCreateBranch); err != nil {
+		return err
+	}
+	return b.Delegate.CreateBranch(repoPath, branch, startPoint)
+}
+
+func (b *FakeBackend) FetchPrune(repoPath string, remote string) error {
+	if err := b.call(OpFetchPrune); err != nil {
+		return err
+	}
+	return b.Delegate.FetchPrune(repoPath, remote)
+}
+
+func (b *FakeBackend) LocalBranches(repoPath string) ([]git.LocalBranchInfo, error) {
+	if err := b.call(OpLocalBranches); err != nil {
+		return nil, err
+	}
+	return b.Delegate.LocalBranches(repoPath)
+}
+
+func (b *FakeBackend) MergedBranches(repoPath string, target string) ([]string, error) {
+	if err := b.call(OpMergedBranches); err != nil {
+		return nil, err
+	}
+	return b.Delegate.MergedBranches(repoPath, target)
+}
+
+func (b *FakeBackend) IsSquashMerged(repoPath string, branch string, target string) (bool, error) {
+	if err := b.call(OpIsSquashMerged); err != nil {
+		return false, err
+	}
+	return b.Delegate.IsSquashMerged(repoPath, branch, target)
+}
+
+func (b *FakeBackend) DeleteBranch(repoPath string, branch string) error {
+	if err := b.call(OpDeleteBranch); err != nil {
+		return err
+	}
+	return b.Delegate.DeleteBranch(repoPath, branch)
+}
+
+func (b *FakeBackend) Log(repoPath string, since string, author string, revisions ...string) ([]git.Commit, error) {
+	if err := b.call(OpLog); err != nil {
+		return nil, err
+	}
+	return b.Delegate.Log(repoPath, since, author, revisions...)
+}
+
+func (b *FakeBackend) Grep(repoPath string, pattern string, ignoreCase bool, pathspecs ...string) ([]git.GrepMatch, error) {
+	if err := b.call(OpGrep); err != nil {
+		return nil, err
+	}
+	return b.Delegate.Grep(repoPath, pattern, ignoreCase, pathspecs...)
+}
+
+func (b *FakeBackend) Reflog(repoPath string) ([]string, error) {
+	if err := b.call(OpReflog); err != nil {
+		return nil, err
+	}
+	return b.Delegate.Reflog(repoPath)
+}
+
+func (b *FakeBackend) ResetKeep(repoPath string, commit string) error {
+	if err := b.call(OpResetKeep); err != nil {
+		return err
+	}
+	return b.Delegate.ResetKeep(repoPath, commit)
+}
+
+func (b *FakeBackend) LastFetch(repoPath string) (time.Time, error) {
+	if err := b.call(OpLastFetch); err != nil {
+		return time.Time{}, err
+	}
+	return b.Delegate.LastFetch(repoPath)
+}
+
+func (b *FakeBackend) StashCount(repoPath string) (int, error) {
+	if err := b.call(OpStashCount); err != nil {
+		return 0, err
+	}
+	return b.Delegate.StashCount(repoPath)
+}
+
+func (b *FakeBackend) CommonDir(repoPath string) (string, error) {
+	if err := b.call(OpCommonDir); err != nil {
+		return "", err
+	}
+	return b.Delegate.CommonDir(repoPath)
+}
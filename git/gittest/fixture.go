@@ -0,0 +1,224 @@
+// Package gittest provides real git repositories in temporary directories,
+// and a scriptable fake Backend for failure injection.
+package gittest
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// DefaultBranch is the branch all fixture repositories start with
+const DefaultBranch = "main"
+
+// Fixture builds local bare "remotes" and clones of them in a temporary directory.
+// Every scenario gets its own remote, so they don't affect each other.
+type Fixture struct {
+	t   testing.TB
+	Dir string
+}
+
+// NewFixture creates a Fixture in a temporary directory, removed after the test
+func NewFixture(t testing.TB) *Fixture {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	return &Fixture{
+		t:   t,
+		Dir: t.TempDir(),
+	}
+}
+
+// Git runs git in the repository and returns its trimmed output. Fails the test on error.
+func (f *Fixture) Git(repoPath string, args ...string) string {
+	f.t.Helper()
+
+	cmd := exec.Command("git", append([]string{"-C", repoPath}, args...)...)
+
+	// A fixed identity and no user or system config make the fixtures reproducible
+	cmd.Env = append(os.Environ(),
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_CONFIG_GLOBAL="+os.DevNull,
+		"GIT_AUTHOR_NAME=Tortuga",
+		"GIT_AUTHOR_EMAIL=tortuga@example.com",
+		"GIT_COMMITTER_NAME=Tortuga",
+		"GIT_COMMITTER_EMAIL=tortuga@example.com",
+		"GIT_TERMINAL_PROMPT=0",
+	)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		f.t.Fatalf("git %s failed: %s\n%s", strings.Join(args, " "), err, output)
+	}
+
+	return strings.TrimSpace(string(output))
+}
+
+// WriteFile writes the content to the file of the repository
+func (f *Fixture) WriteFile(repoPath string, file string, content string) {
+	f.t.Helper()
+
+	err := os.WriteFile(filepath.Join(repoPath, file), []byte(content), 0o644)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+// Commit writes the content to the file and commits it
+func (f *Fixture) Commit(repoPath string, file string, content string) {
+	f.t.Helper()
+
+	f.WriteFile(repoPath, file, content)
+	f.Git(repoPath, "add", file)
+	f.Git(repoPath, "commit", "-q", "-m", fmt.Sprintf("Update %s", file))
+}
+
+// remotePath returns the path of the bare remote of the scenario
+func (f *Fixture) remotePath(name string) string {
+	return filepath.Join(f.Dir, "remotes", name+".git")
+}
+
+// UpToDate creates a remote with an initial commit and a clone tracking it,
+// and returns the path of the clone
+func (f *Fixture) UpToDate(name string) string {
+	f.t.Helper()
+
+	remotePath := f.remotePath(name)
+	err := os.MkdirAll(remotePath, 0o755)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	f.Git(remotePath, "init", "-q", "--bare")
+	f.Git(remotePath, "symbolic-ref", "HEAD", "refs/heads/"+DefaultBranch)
+
+	// The initial commit is pushed by a seed repository
+	seedPath := filepath.Join(f.Dir, "seeds", name)
+	err = os.MkdirAll(seedPath, 0o755)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	f.Git(seedPath, "init", "-q")
+	f.Git(seedPath, "symbolic-ref", "HEAD", "refs/heads/"+DefaultBranch)
+	f.Commit(seedPath, "README", "initial\n")
+	f.Git(seedPath, "push", "-q", remotePath, DefaultBranch)
+
+	clonePath := filepath.Join(f.Dir, name)
+	f.Git(f.Dir, "clone", "-q", remotePath, clonePath)
+
+	return clonePath
+}
+
+// PushRemote adds commits to the remote of the scenario, without touching the clone
+func (f *Fixture) PushRemote(name string, file string, contents ...string) {
+	f.t.Helper()
+
+	otherPath := filepath.Join(f.Dir, "others", name)
+	if _, err := os.Stat(otherPath); os.IsNotExist(err) {
+		f.Git(f.Dir, "clone", "-q", f.remotePath(name), otherPath)
+	} else {
+		f.Git(otherPath, "pull", "-q", "--rebase")
+	}
+
+	for _, content := range contents {
+		f.Commit(otherPath, file, content)
+	}
+	f.Git(otherPath, "push", "-q")
+}
+
+// Incoming creates a clone that is n commits behind its upstream
+func (f *Fixture) Incoming(name string, n int) string {
+	f.t.Helper()
+
+	clonePath := f.UpToDate(name)
+
+	contents := make([]string, n)
+	for i := range contents {
+		contents[i] = fmt.Sprintf("incoming %d\n", i)
+	}
+	f.PushRemote(name, "incoming", contents...)
+
+	f.Git(clonePath, "fetch", "-q")
+	return clonePath
+}
+
+// Outgoing creates a clone that is n commits ahead of its upstream
+func (f *Fixture) Outgoing(name string, n int) string {
+	f.t.Helper()
+
+	clonePath := f.UpToDate(name)
+	for i := 0; i < n; i++ {
+		f.Commit(clonePath, "outgoing", fmt.Sprintf("outgoing %d\n", i))
+	}
+
+	return clonePath
+}
+
+// Conflict creates a clone with a local and a remote commit changing the same file differently,
+// so rebasing fails
+func (f *Fixture) Conflict(name string) string {
+	f.t.Helper()
+
+	clonePath := f.UpToDate(name)
+	f.PushRemote(name, "README", "remote\n")
+	f.Commit(clonePath, "README", "local\n")
+	f.Git(clonePath, "fetch", "-q")
+
+	return clonePath
+}
+
+// Dirty creates a clone with a changed tracked file and an unversioned file
+func (f *Fixture) Dirty(name string) string {
+	f.t.Helper()
+
+	clonePath := f.UpToDate(name)
+	f.WriteFile(clonePath, "README", "changed\n")
+	f.WriteFile(clonePath, "unversioned", "unversioned\n")
+
+	return clonePath
+}
+
+// NoUpstream creates a clone on a local branch without an upstream
+func (f *Fixture) NoUpstream(name string) string {
+	f.t.Helper()
+
+	clonePath := f.UpToDate(name)
+	f.Git(clonePath, "checkout", "-q", "-b", "local-only")
+
+	return clonePath
+}
+
+// Detached creates a clone with a detached HEAD
+func (f *Fixture) Detached(name string) string {
+	f.t.Helper()
+
+	clonePath := f.UpToDate(name)
+	f.Git(clonePath, "checkout", "-q", "--detach")
+
+	return clonePath
+}
+
+// Worktree creates a clone with a linked worktree on another branch tracking a remote branch
+// of the same name, and returns the path of the worktree
+func (f *Fixture) Worktree(name string) string {
+	f.t.Helper()
+
+	clonePath := f.UpToDate(name)
+	f.Git(clonePath, "push", "-q", "origin", DefaultBranch+":"+name)
+
+	worktreePath := filepath.Join(f.Dir, "worktrees", name)
+	f.Git(clonePath, "worktree", "add", "-q", "--track", "-b", name, worktreePath, "origin/"+name)
+
+	return worktreePath
+}
+
+// Head returns the commit hash of the revision
+func (f *Fixture) Head(repoPath string, revision string) string {
+	f.t.Helper()
+	return f.Git(repoPath, "rev-parse", revision)
+}
@@ -0,0 +1,170 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// GoGitBackend runs the read-only operations in-process with go-git, which saves
+// a process spawn per call when scanning many repositories. All operations
+// changing a repository or accessing a remote are still run by the git executable.
+// Counting incoming and outgoing commits is left to git, too, because a walk ordered
+// by commit dates miscounts commits with equal or skewed dates.
+type GoGitBackend struct {
+	ExecBackend
+}
+
+// NewGoGitBackend returns a Backend with in-process read-only operations
+func NewGoGitBackend() *GoGitBackend {
+	return &GoGitBackend{}
+}
+
+// open returns the go-git repository of the path. It's opened for every operation,
+// because a fetch by the git executable might add objects and refs in the meantime.
+// The refs and config of linked worktrees are in the common git dir.
+func (b *GoGitBackend) open(repoPath string) (*gogit.Repository, error) {
+	return gogit.PlainOpenWithOptions(repoPath, &gogit.PlainOpenOptions{
+		DetectDotGit:          true,
+		EnableDotGitCommonDir: true,
+	})
+}
+
+func (b *GoGitBackend) LocalBranch(repoPath string) (string, error) {
+	repo, err := b.open(repoPath)
+	if err != nil {
+		return "", err
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", err
+	}
+
+	if !head.Name().IsBranch() {
+		return "HEAD", errors.New("not on a branch")
+	}
+
+	return head.Name().Short(), nil
+}
+
+// trackingRef returns the remote and the remote-tracking reference of the branch,
+// resolved either for pulling (upstream) or pushing
+func (b *GoGitBackend) trackingRef(repo *gogit.Repository, branch string, push bool) (string, plumbing.ReferenceName, error) {
+	cfg, err := repo.Config()
+	if err != nil {
+		return "", "", err
+	}
+
+	branchCfg, ok := cfg.Branches[branch]
+	if !ok || len(branchCfg.Remote) == 0 || len(branchCfg.Merge) == 0 {
+		return "", "", errors.New("no upstream")
+	}
+
+	if !push {
+		return branchCfg.Remote, plumbing.NewRemoteReferenceName(branchCfg.Remote, branchCfg.Merge.Short()), nil
+	}
+
+	// The push remote might differ, but the branch name is always the same,
+	// like with the default "simple" push strategy
+	remote := branchCfg.Remote
+	if section := cfg.Raw.Section("remote"); section != nil {
+		if pushDefault := section.Options.Get("pushDefault"); len(pushDefault) > 0 {
+			remote = pushDefault
+		}
+	}
+	if pushRemote := cfg.Raw.Section("branch").Subsection(branch).Options.Get("pushRemote"); len(pushRemote) > 0 {
+		remote = pushRemote
+	}
+
+	return remote, plumbing.NewRemoteReferenceName(remote, branch), nil
+}
+
+func (b *GoGitBackend) UpstreamBranch(repoPath string) (string, error) {
+	branch, err := b.LocalBranch(repoPath)
+	if err != nil {
+		return "", err
+	}
+
+	repo, err := b.open(repoPath)
+	if err != nil {
+		return "", err
+	}
+
+	_, ref, err := b.trackingRef(repo, branch, false)
+	if err != nil {
+		return "", err
+	}
+
+	return ref.Short(), nil
+}
+
+func (b *GoGitBackend) Status(repoPath string) (StatusCounts, error) {
+	repo, err := b.open(repoPath)
+	if err != nil {
+		return StatusCounts{}, err
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return StatusCounts{}, err
+	}
+
+	status, err := worktree.Status()
+	if err != nil {
+		return StatusCounts{}, err
+	}
+
+	var counts StatusCounts
+	for _, fileStatus := range status {
+		switch {
+		case fileStatus.Staging == gogit.Untracked && fileStatus.Worktree == gogit.Untracked:
+			counts.Unversioned++
+		case fileStatus.Staging != gogit.Unmodified || fileStatus.Worktree != gogit.Unmodified:
+			counts.Changes++
+		}
+	}
+
+	return counts, nil
+}
+
+func (b *GoGitBackend) CommitDate(repoPath string, revision string) (time.Time, error) {
+	repo, err := b.open(repoPath)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return commit.Committer.When, nil
+}
+
+func (b *GoGitBackend) RemoteURL(repoPath string, remote string) (string, error) {
+	repo, err := b.open(repoPath)
+	if err != nil {
+		return "", err
+	}
+
+	r, err := repo.Remote(remote)
+	if err != nil {
+		return "", err
+	}
+
+	urls := r.Config().URLs
+	if len(urls) == 0 {
+		return "", fmt.Errorf("remote '%s' has no URL", remote)
+	}
+
+	return urls[0], nil
+}
@@ -0,0 +1,86 @@
+package git
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// FetchProgress is the progress of a fetch, as reported by git
+type FetchProgress struct {
+	// Stage is "Receiving objects", "Unpacking objects" instead for small fetches, or "Resolving deltas"
+	Stage string
+
+	// Percent of the stage done so far
+	Percent int
+
+	// Rate is the transfer rate as shown by git, e.g. "2.40 MiB/s". Only known while receiving.
+	Rate string
+}
+
+var (
+	// fetchProgressRegexp matches the reported stages, e.g.
+	// "Receiving objects:  45% (450/1000), 1.20 MiB | 2.40 MiB/s"
+	fetchProgressRegexp = regexp.MustCompile(`^(Receiving objects|Unpacking objects|Resolving deltas):\s+(\d+)% \(\d+/\d+\)(?:, [^|]+\| ([^,]+))?`)
+
+	// otherProgressRegexp matches all other progress, like "remote: Compressing objects:  50% (1/2)"
+	// or "remote: Enumerating objects: 5, done."
+	otherProgressRegexp = regexp.MustCompile(`^(?:remote: )?[A-Za-z ]+:\s+(?:\d+% \(\d+/\d+\)|\d+, done\.)`)
+)
+
+// parseFetchProgress parses a progress line of git, returns false if it's no reported stage
+func parseFetchProgress(line string) (FetchProgress, bool) {
+	match := fetchProgressRegexp.FindStringSubmatch(line)
+	if match == nil {
+		return FetchProgress{}, false
+	}
+
+	percent, _ := strconv.Atoi(match[2])
+
+	return FetchProgress{
+		Stage:   match[1],
+		Percent: percent,
+		Rate:    match[3],
+	}, true
+}
+
+// progressWriter splits the StdErr of git into lines, which are redrawn with carriage returns.
+// The progress is passed on, all other lines are written to the errors, so they are available for errors.
+type progressWriter struct {
+	progress func(FetchProgress)
+	errors   io.Writer
+	line     []byte
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		if b == '\r' || b == '\n' {
+			w.flush()
+			continue
+		}
+		w.line = append(w.line, b)
+	}
+	return len(p), nil
+}
+
+// flush handles the current line
+func (w *progressWriter) flush() {
+	line := string(w.line)
+	w.line = w.line[:0]
+
+	if len(line) == 0 {
+		return
+	}
+
+	if progress, ok := parseFetchProgress(line); ok {
+		w.progress(progress)
+		return
+	}
+
+	if otherProgressRegexp.MatchString(line) {
+		return
+	}
+
+	fmt.Fprintln(w.errors, line)
+}
@@ -0,0 +1,61 @@
+package git
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestParseFetchProgress(t *testing.T) {
+	tests := []struct {
+		line   string
+		want   FetchProgress
+		wantOk bool
+	}{
+		{"Receiving objects:  45% (450/1000), 1.20 MiB | 2.40 MiB/s", FetchProgress{"Receiving objects", 45, "2.40 MiB/s"}, true},
+		{"Receiving objects: 100% (1000/1000), 2.50 MiB | 2.40 MiB/s, done.", FetchProgress{"Receiving objects", 100, "2.40 MiB/s"}, true},
+		{"Receiving objects:   3% (30/1000)", FetchProgress{"Receiving objects", 3, ""}, true},
+		{"Unpacking objects:  22% (5/22), 1.20 MiB | 2.40 MiB/s", FetchProgress{"Unpacking objects", 22, "2.40 MiB/s"}, true},
+		{"Resolving deltas:  30% (30/100)", FetchProgress{"Resolving deltas", 30, ""}, true},
+		{"remote: Compressing objects:  50% (1/2)", FetchProgress{}, false},
+		{"From github.com:benweidig/tortuga", FetchProgress{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, ok := parseFetchProgress(tt.line)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("parseFetchProgress() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestProgressWriter(t *testing.T) {
+	var errors bytes.Buffer
+	var progress []FetchProgress
+
+	w := &progressWriter{
+		progress: func(p FetchProgress) { progress = append(progress, p) },
+		errors:   &errors,
+	}
+
+	// Progress is redrawn with carriage returns, and might be split anywhere
+	w.Write([]byte("remote: Enumerating objects: 5, done.\nremote: Compressing objects:  50% (1/2)\rReceiving obj"))
+	w.Write([]byte("ects:  50% (1/2)\rReceiving objects: 100% (2/2), done.\nfatal: the remote end hung up\n"))
+	w.Write([]byte("Resolving deltas:  30% (3/10)"))
+	w.flush()
+
+	want := []FetchProgress{
+		{"Receiving objects", 50, ""},
+		{"Receiving objects", 100, ""},
+		{"Resolving deltas", 30, ""},
+	}
+	if !reflect.DeepEqual(progress, want) {
+		t.Errorf("progress = %v, want %v", progress, want)
+	}
+
+	if errors.String() != "fatal: the remote end hung up\n" {
+		t.Errorf("errors = %q, want only the fatal line", errors.String())
+	}
+}
@@ -0,0 +1,168 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/benweidig/tortuga/repo"
+)
+
+// Run is a single sync of multiple repositories
+type Run struct {
+	ID           string    `json:"id"`
+	Started      time.Time `json:"started"`
+	IncomingOnly bool      `json:"incomingOnly"`
+	Entries      []Entry   `json:"entries"`
+}
+
+// Entry is what a sync did to a single repository
+type Entry struct {
+	Repository  string        `json:"repository"`
+	Path        string        `json:"path"`
+	Branch      string        `json:"branch"`
+	OldHead     string        `json:"oldHead"`
+	NewHead     string        `json:"newHead"`
+	PushedRange string        `json:"pushedRange,omitempty"`
+	StashRef    string        `json:"stashRef,omitempty"`
+	Duration    time.Duration `json:"duration"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// ErrRunNotFound is returned if there's no run with the requested ID
+var ErrRunNotFound = errors.New("run not found")
+
+// NewRun creates a Run of all repositories that were synced
+func NewRun(started time.Time, incomingOnly bool, repos []*repo.Repository) Run {
+	run := Run{
+		ID:           started.Format("20060102-150405"),
+		Started:      started,
+		IncomingOnly: incomingOnly,
+	}
+
+	for _, r := range repos {
+		if r.SyncRecord == nil {
+			continue
+		}
+
+		entry := Entry{
+			Repository:  r.Name,
+			Path:        r.Path(),
+			Branch:      r.Branch,
+			OldHead:     r.SyncRecord.OldHead,
+			NewHead:     r.SyncRecord.NewHead,
+			PushedRange: r.SyncRecord.PushedRange,
+			StashRef:    r.SyncRecord.StashRef,
+			Duration:    r.SyncRecord.Duration,
+		}
+		if r.State == repo.StateError || r.State == repo.StateHookFailed {
+			entry.Error = r.Error.Error()
+		}
+
+		run.Entries = append(run.Entries, entry)
+	}
+
+	return run
+}
+
+// DefaultPath returns the path of the history file in the user's state directory,
+// e.g. $XDG_STATE_HOME/tortuga/history.jsonl
+func DefaultPath() (string, error) {
+	stateDir := os.Getenv("XDG_STATE_HOME")
+	if len(stateDir) == 0 {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		stateDir = filepath.Join(homeDir, ".local", "state")
+	}
+	return filepath.Join(stateDir, "tortuga", "history.jsonl"), nil
+}
+
+// Append adds the Run as a single line to the history file.
+// Runs started in the same second get a suffix, e.g. "20240131-120000-2", so their IDs stay unique.
+func Append(filePath string, run Run) error {
+	err := os.MkdirAll(filepath.Dir(filePath), 0o755)
+	if err != nil {
+		return err
+	}
+
+	runs, err := Read(filePath)
+	if err != nil {
+		return err
+	}
+	run.ID = uniqueID(runs, run.ID)
+
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(append(data, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Read returns all runs of the history file, oldest first. A missing file is an empty history.
+func Read(filePath string) ([]Run, error) {
+	f, err := os.Open(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Run{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var runs []Run
+
+	scanner := bufio.NewScanner(f)
+	// A run of many repositories easily exceeds the default line limit
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		var run Run
+		err = json.Unmarshal(scanner.Bytes(), &run)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, scanner.Err()
+}
+
+// uniqueID returns the ID, or the ID with the first free suffix if a run already has it
+func uniqueID(runs []Run, id string) string {
+	taken := map[string]bool{}
+	for _, run := range runs {
+		taken[run.ID] = true
+	}
+
+	unique := id
+	for suffix := 2; taken[unique]; suffix++ {
+		unique = fmt.Sprintf("%s-%d", id, suffix)
+	}
+	return unique
+}
+
+// Find returns the run with the ID
+func Find(runs []Run, id string) (Run, error) {
+	for _, run := range runs {
+		if run.ID == id {
+			return run, nil
+		}
+	}
+	return Run{}, ErrRunNotFound
+}
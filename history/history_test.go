@@ -0,0 +1,41 @@
+package history_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/benweidig/tortuga/history"
+)
+
+func TestAppendUniqueIDs(t *testing.T) {
+	historyPath := filepath.Join(t.TempDir(), "history.jsonl")
+	started := time.Date(2024, 1, 31, 12, 0, 0, 0, time.Local)
+
+	for i := 0; i < 3; i++ {
+		run := history.NewRun(started.Add(time.Duration(i)*time.Millisecond), false, nil)
+		err := history.Append(historyPath, run)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runs, err := history.Read(historyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"20240131-120000", "20240131-120000-2", "20240131-120000-3"}
+	if len(runs) != len(want) {
+		t.Fatalf("runs = %d, want %d", len(runs), len(want))
+	}
+	for idx, run := range runs {
+		if run.ID != want[idx] {
+			t.Errorf("ID of run %d = %q, want %q", idx, run.ID, want[idx])
+		}
+	}
+
+	if _, err := history.Find(runs, "20240131-120000-2"); err != nil {
+		t.Errorf("run with suffix not found: %v", err)
+	}
+}
@@ -0,0 +1,202 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/benweidig/tortuga/config"
+	"github.com/benweidig/tortuga/repo"
+)
+
+// Events of a run hooks can be notified of
+const (
+	// EventIncoming means there are new incoming commits
+	EventIncoming = "incoming"
+
+	// EventErrors means there are repositories with errors
+	EventErrors = "errors"
+)
+
+// Events are all events hooks can be notified of
+var Events = []string{EventIncoming, EventErrors}
+
+// Timeout of a single hook
+var Timeout = 30 * time.Second
+
+// Summary is what hooks are notified of, as JSON
+type Summary struct {
+	Time   time.Time `json:"time"`
+	Events []string  `json:"events"`
+	Behind []Behind  `json:"behind"`
+	Errors []Error   `json:"errors"`
+	Pushed []Pushed  `json:"pushed"`
+}
+
+// Behind is a repository with incoming commits
+type Behind struct {
+	Repository string `json:"repository"`
+	Path       string `json:"path"`
+	Branch     string `json:"branch"`
+	Incoming   int    `json:"incoming"`
+	Synced     bool   `json:"synced"`
+}
+
+// Error is a repository with an error
+type Error struct {
+	Repository string `json:"repository"`
+	Path       string `json:"path"`
+	Branch     string `json:"branch"`
+	Error      string `json:"error"`
+}
+
+// Pushed is a repository whose outgoing commits were pushed
+type Pushed struct {
+	Repository string `json:"repository"`
+	Path       string `json:"path"`
+	Branch     string `json:"branch"`
+	Range      string `json:"range"`
+	Commits    int    `json:"commits"`
+}
+
+// NewSummary creates the Summary of the repositories, and the events it's about
+func NewSummary(repos []*repo.Repository) Summary {
+	summary := Summary{
+		Time:   time.Now(),
+		Events: []string{},
+		Behind: []Behind{},
+		Errors: []Error{},
+		Pushed: []Pushed{},
+	}
+
+	for _, r := range repos {
+		if r.State == repo.StateError || r.State == repo.StateHookFailed {
+			summary.Errors = append(summary.Errors, Error{
+				Repository: r.Name,
+				Path:       r.Path(),
+				Branch:     r.Branch,
+				Error:      r.Error.Error(),
+			})
+		}
+
+		// Nothing was done after an error, but a failed hook might be after pulling or pushing
+		if r.State == repo.StateError {
+			continue
+		}
+
+		if r.Incoming > 0 {
+			summary.Behind = append(summary.Behind, Behind{
+				Repository: r.Name,
+				Path:       r.Path(),
+				Branch:     r.Branch,
+				Incoming:   r.Incoming,
+				Synced:     r.State == repo.StateSynced,
+			})
+		}
+
+		if r.SyncRecord != nil && len(r.SyncRecord.PushedRange) > 0 {
+			summary.Pushed = append(summary.Pushed, Pushed{
+				Repository: r.Name,
+				Path:       r.Path(),
+				Branch:     r.Branch,
+				Range:      r.SyncRecord.PushedRange,
+				Commits:    r.Outgoing,
+			})
+		}
+	}
+
+	if len(summary.Behind) > 0 {
+		summary.Events = append(summary.Events, EventIncoming)
+	}
+	if len(summary.Errors) > 0 {
+		summary.Events = append(summary.Events, EventErrors)
+	}
+
+	return summary
+}
+
+// Notify notifies the hook of the summary, if it's about any of the events of the hook
+func (s Summary) Notify(hook config.NotifyConfig) error {
+	for _, event := range hook.Events {
+		if !slices.Contains(Events, event) {
+			return fmt.Errorf("unknown event '%s'", event)
+		}
+	}
+	if len(hook.Command) == 0 && len(hook.Webhook) == 0 {
+		return errors.New("hook needs a command or a webhook")
+	}
+
+	events := s.Events
+	if len(hook.Events) > 0 {
+		events = slices.DeleteFunc(slices.Clone(events), func(event string) bool {
+			return !slices.Contains(hook.Events, event)
+		})
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	// The payload only tells the events of the hook, like TORTUGA_EVENTS
+	filtered := s
+	filtered.Events = events
+
+	payload, err := json.Marshal(filtered)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if len(hook.Command) > 0 {
+		errs = append(errs, runCommand(hook.Command, payload, events))
+	}
+	if len(hook.Webhook) > 0 {
+		errs = append(errs, postWebhook(hook.Webhook, payload))
+	}
+	return errors.Join(errs...)
+}
+
+// runCommand runs the command with the payload on its StdIn, and the events in TORTUGA_EVENTS
+func runCommand(command string, payload []byte, events []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+
+	cmd := repo.ShellCommand(ctx, command)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(), "TORTUGA_EVENTS="+strings.Join(events, ","))
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	if err != nil && output.Len() > 0 {
+		return fmt.Errorf("command '%s': %w: %s", command, err, strings.TrimSpace(output.String()))
+	}
+	if err != nil {
+		return fmt.Errorf("command '%s': %w", command, err)
+	}
+	return nil
+}
+
+// postWebhook posts the payload as JSON to the URL
+func postWebhook(url string, payload []byte) error {
+	client := http.Client{Timeout: Timeout}
+
+	resp, err := client.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook '%s': %s", url, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package notify_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/benweidig/tortuga/config"
+	"github.com/benweidig/tortuga/notify"
+	"github.com/benweidig/tortuga/repo"
+)
+
+func summaryRepositories() []*repo.Repository {
+	return []*repo.Repository{
+		{Name: "behind", Branch: "main", State: repo.StateRemoteFetched, Incoming: 2},
+		{Name: "pushed", Branch: "main", State: repo.StateSynced, Outgoing: 1, SyncRecord: &repo.SyncRecord{PushedRange: "a..b"}},
+		{Name: "broken", Branch: "main", State: repo.StateError, Error: errors.New("auth error")},
+		{Name: "clean", Branch: "main", State: repo.StateRemoteFetched},
+	}
+}
+
+func TestNewSummary(t *testing.T) {
+	summary := notify.NewSummary(summaryRepositories())
+
+	if want := []string{notify.EventIncoming, notify.EventErrors}; !reflect.DeepEqual(summary.Events, want) {
+		t.Errorf("events = %v, want %v", summary.Events, want)
+	}
+	if len(summary.Behind) != 1 || summary.Behind[0].Repository != "behind" || summary.Behind[0].Incoming != 2 {
+		t.Errorf("behind = %+v, want only behind with 2 incoming", summary.Behind)
+	}
+	if len(summary.Errors) != 1 || summary.Errors[0].Error != "auth error" {
+		t.Errorf("errors = %+v, want only broken", summary.Errors)
+	}
+	if len(summary.Pushed) != 1 || summary.Pushed[0].Range != "a..b" || summary.Pushed[0].Commits != 1 {
+		t.Errorf("pushed = %+v, want only pushed with a..b", summary.Pushed)
+	}
+
+	if events := notify.NewSummary(nil).Events; len(events) != 0 {
+		t.Errorf("events without repositories = %v, want none", events)
+	}
+}
+
+func TestNewSummaryHookFailed(t *testing.T) {
+	summary := notify.NewSummary([]*repo.Repository{
+		{Name: "hooked", Branch: "main", State: repo.StateHookFailed, Outgoing: 1, SyncRecord: &repo.SyncRecord{PushedRange: "a..b"}, Error: errors.New("post-sync hook failed: exit status 1")},
+	})
+
+	if len(summary.Errors) != 1 || summary.Errors[0].Repository != "hooked" {
+		t.Errorf("errors = %+v, want only hooked", summary.Errors)
+	}
+	if len(summary.Pushed) != 1 {
+		t.Errorf("pushed = %+v, want hooked as pushed before its hook failed", summary.Pushed)
+	}
+}
+
+func TestNotifyCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	out := filepath.Join(t.TempDir(), "out")
+	summary := notify.NewSummary(summaryRepositories())
+
+	err := summary.Notify(config.NotifyConfig{Command: `echo "$TORTUGA_EVENTS" > ` + out + ` && cat >> ` + out})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, _ := json.Marshal(summary)
+	if want := "incoming,errors\n" + string(payload); string(content) != want {
+		t.Errorf("command got %q, want %q", content, want)
+	}
+
+	if err := summary.Notify(config.NotifyConfig{Command: "exit 3"}); err == nil {
+		t.Error("failing command didn't fail")
+	}
+}
+
+func TestNotifyWebhook(t *testing.T) {
+	var received []notify.Summary
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var summary notify.Summary
+		if err := json.Unmarshal(body, &summary); err != nil {
+			t.Error(err)
+		}
+		received = append(received, summary)
+	}))
+	defer server.Close()
+
+	errorsOnly := config.NotifyConfig{Events: []string{notify.EventErrors}, Webhook: server.URL}
+
+	// Only incoming commits aren't an event of the hook
+	err := notify.NewSummary(summaryRepositories()[:1]).Notify(errorsOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 0 {
+		t.Fatalf("webhook notified of %v, want nothing", received[0].Events)
+	}
+
+	err = notify.NewSummary(summaryRepositories()).Notify(errorsOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 1 || len(received[0].Errors) != 1 {
+		t.Fatalf("webhook received %+v, want a single summary with an error", received)
+	}
+	if want := []string{notify.EventErrors}; !reflect.DeepEqual(received[0].Events, want) {
+		t.Errorf("webhook received events %v, want only %v", received[0].Events, want)
+	}
+
+	if err := notify.NewSummary(summaryRepositories()).Notify(config.NotifyConfig{Events: []string{"pushed"}, Webhook: server.URL}); err == nil {
+		t.Error("unknown event didn't fail")
+	}
+}
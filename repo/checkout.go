@@ -0,0 +1,157 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CheckoutState represents the outcome of switching to or creating a branch in a Repository
+type CheckoutState int
+
+const (
+	// CheckoutStateSwitched means the Repository was switched to an existing branch
+	CheckoutStateSwitched CheckoutState = iota
+
+	// CheckoutStateCreated means the branch was created and switched to
+	CheckoutStateCreated
+
+	// CheckoutStateCurrent means the branch was already checked out, nothing was done
+	CheckoutStateCurrent
+
+	// CheckoutStateNoSuchBranch means the Repository doesn't have the branch, locally or remote
+	CheckoutStateNoSuchBranch
+
+	// CheckoutStateBranchExists means the branch to be created already exists
+	CheckoutStateBranchExists
+
+	// CheckoutStateLocalChanges means the Repository was left alone due to changes in the working tree
+	CheckoutStateLocalChanges
+
+	// CheckoutStateError indicates any kind of error
+	CheckoutStateError
+)
+
+// CheckoutResult represents the outcome of switching to or creating a branch in a Repository
+type CheckoutResult struct {
+	State CheckoutState
+	Error error
+
+	// Branch is the branch switched to, and UpstreamBranch its upstream, if any.
+	// Only set if switched or created, see ApplyCheckout.
+	Branch         string
+	UpstreamBranch string
+}
+
+func checkoutError(err error) *CheckoutResult {
+	return &CheckoutResult{
+		State: CheckoutStateError,
+		Error: err,
+	}
+}
+
+// Checkout switches the Repository to the branch. If the branch doesn't exist
+// it's created from the default branch of the remote if requested.
+// Changes in the working tree are only carried over with autostash.
+func (r *Repository) Checkout(branch string, create bool, autostash bool) *CheckoutResult {
+	if r.Branch == branch {
+		return &CheckoutResult{State: CheckoutStateCurrent}
+	}
+
+	exists, err := r.backend.HasBranch(r.path, branch)
+	if err != nil {
+		return checkoutError(err)
+	}
+
+	if !exists {
+		if !create {
+			return &CheckoutResult{State: CheckoutStateNoSuchBranch}
+		}
+
+		defaultBranch, err := r.backend.DefaultBranch(r.path, r.remoteName())
+		if err != nil {
+			return checkoutError(fmt.Errorf("no default branch: %w", err))
+		}
+
+		return r.switchBranch(branch, autostash, CheckoutStateCreated, func() error {
+			return r.backend.CreateBranch(r.path, branch, defaultBranch)
+		})
+	}
+
+	return r.switchBranch(branch, autostash, CheckoutStateSwitched, func() error {
+		return r.backend.Checkout(r.path, branch)
+	})
+}
+
+// CreateBranch creates a new branch at the start point and switches to it.
+// An empty start point uses the current HEAD.
+// Changes in the working tree are only carried over with autostash.
+func (r *Repository) CreateBranch(branch string, startPoint string, autostash bool) *CheckoutResult {
+	exists, err := r.backend.HasBranch(r.path, branch)
+	if err != nil {
+		return checkoutError(err)
+	}
+
+	if exists {
+		return &CheckoutResult{State: CheckoutStateBranchExists}
+	}
+
+	return r.switchBranch(branch, autostash, CheckoutStateCreated, func() error {
+		return r.backend.CreateBranch(r.path, branch, startPoint)
+	})
+}
+
+// switchBranch runs the actual switch and takes care of the local changes
+func (r *Repository) switchBranch(branch string, autostash bool, successState CheckoutState, fn func() error) *CheckoutResult {
+	err := r.updateChanges()
+	if err != nil {
+		return checkoutError(err)
+	}
+
+	stashed := false
+	if r.Changes > 0 {
+		if !autostash {
+			return &CheckoutResult{State: CheckoutStateLocalChanges}
+		}
+
+		err = r.backend.StashSave(r.path)
+		if err != nil {
+			return checkoutError(err)
+		}
+		stashed = true
+	}
+
+	err = fn()
+	if err != nil {
+		if stashed {
+			r.backend.StashPop(r.path)
+		}
+		return checkoutError(err)
+	}
+
+	if stashed {
+		err = r.backend.StashPop(r.path)
+		if err != nil {
+			return checkoutError(err)
+		}
+	}
+
+	// The new branch might not have an upstream at all
+	upstreamBranch, _ := r.backend.UpstreamBranch(r.path)
+
+	return &CheckoutResult{State: successState, Branch: branch, UpstreamBranch: upstreamBranch}
+}
+
+// ApplyCheckout updates the branch and remote of the Repository after it was switched to another branch.
+// It's not done by the checkout itself, as the Repository is rendered meanwhile.
+func (r *Repository) ApplyCheckout(result *CheckoutResult) {
+	if result.State != CheckoutStateSwitched && result.State != CheckoutStateCreated {
+		return
+	}
+
+	r.Branch = result.Branch
+	r.UpstreamBranch = result.UpstreamBranch
+	r.Remote = ""
+	if len(result.UpstreamBranch) > 0 {
+		r.Remote = strings.Split(result.UpstreamBranch, "/")[0]
+	}
+}
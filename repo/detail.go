@@ -0,0 +1,45 @@
+package repo
+
+import (
+	"io/fs"
+	"path/filepath"
+)
+
+// Detail is an optional detail of a Repository, only gathered on request because it's expensive
+type Detail int
+
+const (
+	// DetailLastFetch is when the repository was fetched the last time
+	DetailLastFetch Detail = 1 << iota
+
+	// DetailStashes is the count of stash entries
+	DetailStashes
+
+	// DetailSize is the size on disk, including the git dir
+	DetailSize
+)
+
+// diskUsage sums up the sizes of all files in the directory, without following symlinks
+func diskUsage(dirPath string) (int64, error) {
+	var size int64
+
+	err := filepath.WalkDir(dirPath, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+
+		return nil
+	})
+
+	return size, err
+}
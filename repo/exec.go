@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// ExecResult represents the outcome of an arbitrary command run in a Repository
+type ExecResult struct {
+	Running  bool
+	ExitCode int
+	Output   []byte
+	Error    error
+}
+
+// Failed returns true if the command couldn't be run or exited with a non-zero code
+func (e *ExecResult) Failed() bool {
+	return e.Error != nil || e.ExitCode != 0
+}
+
+// ShellCommand returns a command run by the shell of the platform, so it can use pipes, variables, etc.
+func ShellCommand(ctx context.Context, command string) *exec.Cmd {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+
+	// Only the shell is killed when the context is done, its children might keep the output open
+	cmd.WaitDelay = shellWaitDelay
+	return cmd
+}
+
+// shellWaitDelay is how long the output of a killed shell command is waited for
+const shellWaitDelay = time.Second
+
+// Exec runs an arbitrary command in the working tree of the Repository.
+// Stdout and stderr are combined, like they would appear in a terminal.
+func (r *Repository) Exec(name string, args ...string) *ExecResult {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = r.path
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	result := &ExecResult{}
+
+	err := cmd.Run()
+	result.Output = output.Bytes()
+
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			result.ExitCode = exitErr.ExitCode()
+		} else {
+			result.ExitCode = -1
+			result.Error = err
+		}
+	}
+
+	return result
+}
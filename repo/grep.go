@@ -0,0 +1,20 @@
+package repo
+
+import (
+	"github.com/benweidig/tortuga/git"
+)
+
+// GrepResult represents the lines of a Repository matching a grep pattern
+type GrepResult struct {
+	Matches []git.GrepMatch
+	Error   error
+}
+
+// Grep searches the tracked files of the Repository for lines matching the pattern
+func (r *Repository) Grep(pattern string, ignoreCase bool, pathspecs ...string) *GrepResult {
+	matches, err := r.backend.Grep(r.path, pattern, ignoreCase, pathspecs...)
+	return &GrepResult{
+		Matches: matches,
+		Error:   err,
+	}
+}
@@ -0,0 +1,98 @@
+package repo
+
+import (
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/benweidig/tortuga/git"
+)
+
+// Group is a set of repositories sharing an object store, like worktrees of the same
+// repository, or clones made with '--reference'. They are updated one after another,
+// so objects are only downloaded once, and each remote of a git dir is only fetched once.
+type Group struct {
+	Repositories []*Repository
+
+	commonDirs  map[*Repository]string
+	fetched     map[string]error
+	lastFetches map[string]time.Time
+}
+
+// GroupRepositories groups the repositories by their object store. The repositories owning
+// an object store come first in their group, so the borrowing ones find the objects already present.
+// Groups and repositories keep the order of the provided repositories otherwise.
+func GroupRepositories(repos []*Repository) []*Group {
+	var groups []*Group
+	groupsByStore := map[string]*Group{}
+	owners := map[*Repository]bool{}
+
+	for _, r := range repos {
+		commonDir, err := r.backend.CommonDir(r.path)
+		if err != nil {
+			// Can't be shared, so it gets its own group
+			groups = append(groups, newGroup(r, ""))
+			continue
+		}
+
+		objectStore := git.ObjectStore(commonDir)
+		owners[r] = objectStore == filepath.Join(commonDir, "objects")
+
+		group, ok := groupsByStore[objectStore]
+		if !ok {
+			group = newGroup(r, commonDir)
+			groupsByStore[objectStore] = group
+			groups = append(groups, group)
+			continue
+		}
+
+		group.Repositories = append(group.Repositories, r)
+		group.commonDirs[r] = commonDir
+	}
+
+	for _, group := range groups {
+		sort.SliceStable(group.Repositories, func(i, j int) bool {
+			return owners[group.Repositories[i]] && !owners[group.Repositories[j]]
+		})
+	}
+
+	return groups
+}
+
+func newGroup(r *Repository, commonDir string) *Group {
+	return &Group{
+		Repositories: []*Repository{r},
+		commonDirs:   map[*Repository]string{r: commonDir},
+		fetched:      map[string]error{},
+		lastFetches:  map[string]time.Time{},
+	}
+}
+
+// Update updates the repository of the Group like Repository.Update, but a remote
+// already fetched for the same git dir isn't fetched again. Its result is shared instead.
+// The repositories of a Group must be updated one after another, not in parallel.
+func (g *Group) Update(r *Repository) error {
+	commonDir := g.commonDirs[r]
+	if len(commonDir) == 0 {
+		return r.Update()
+	}
+
+	return r.update(func() error {
+		// Fetching rewrites FETCH_HEAD of the shared git dir, so the repositories after
+		// the first one get the last fetch before the group was fetched
+		if lastFetch, ok := g.lastFetches[commonDir]; ok {
+			r.LastFetch = lastFetch
+		} else {
+			g.lastFetches[commonDir] = r.LastFetch
+		}
+
+		key := commonDir + "\x00" + r.Remote
+
+		err, ok := g.fetched[key]
+		if !ok {
+			err = r.fetch()
+			g.fetched[key] = err
+		}
+		return err
+	})
+}
@@ -0,0 +1,72 @@
+package repo_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/benweidig/tortuga/git/gittest"
+	"github.com/benweidig/tortuga/repo"
+)
+
+func TestGroupRepositories(t *testing.T) {
+	f := gittest.NewFixture(t)
+
+	clonePath := f.UpToDate("shared")
+	f.Git(clonePath, "config", "push.default", "upstream")
+
+	worktreePath := filepath.Join(f.Dir, "shared-worktree")
+	f.Git(clonePath, "worktree", "add", "-q", "--track", "-b", "worktree", worktreePath, "origin/"+gittest.DefaultBranch)
+
+	// The reference clone borrows the objects of the first clone, but has its own git dir
+	referencePath := filepath.Join(f.Dir, "shared-reference")
+	f.Git(f.Dir, "clone", "-q", "--reference", clonePath, f.Git(clonePath, "remote", "get-url", "origin"), referencePath)
+
+	otherPath := f.UpToDate("other")
+
+	f.PushRemote("shared", "incoming", "incoming 1\n", "incoming 2\n")
+
+	backend := gittest.NewFakeBackend()
+
+	var repos []*repo.Repository
+	for _, repoPath := range []string{referencePath, worktreePath, otherPath, clonePath} {
+		r, err := repo.NewRepositoryWithBackend(repoPath, backend)
+		if err != nil {
+			t.Fatal(err)
+		}
+		repos = append(repos, r)
+	}
+
+	groups := repo.GroupRepositories(repos)
+	if len(groups) != 2 {
+		t.Fatalf("groups = %d, want 2", len(groups))
+	}
+
+	shared := groups[0]
+	if len(shared.Repositories) != 3 {
+		t.Fatalf("shared repositories = %d, want 3", len(shared.Repositories))
+	}
+	if shared.Repositories[2] != repos[0] {
+		t.Errorf("reference clone isn't updated last")
+	}
+
+	for _, r := range shared.Repositories {
+		err := shared.Update(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Incoming != 2 {
+			t.Errorf("%s: incoming = %d, want 2", r.Name, r.Incoming)
+		}
+	}
+
+	// The worktree shares the fetch of its main working tree, the reference clone needs its own
+	fetches := 0
+	for _, call := range backend.Calls() {
+		if call == gittest.OpFetch {
+			fetches++
+		}
+	}
+	if fetches != 2 {
+		t.Errorf("fetches = %d, want 2", fetches)
+	}
+}
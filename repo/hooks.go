@@ -0,0 +1,79 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// HookTimeout is how long a hook may run before it's killed and fails
+var HookTimeout = 10 * time.Minute
+
+// Hooks are commands run by the shell in the working tree while syncing. Empty ones are skipped.
+type Hooks struct {
+	// PostPull runs after incoming commits were applied, with TORTUGA_OLD_HEAD and TORTUGA_NEW_HEAD
+	PostPull string
+
+	// PrePush runs before pushing, a failure prevents the push
+	PrePush string
+
+	// PostSync runs after a successful sync
+	PostSync string
+}
+
+// HookError is a failed hook, with its combined output
+type HookError struct {
+	Hook   string
+	Output []byte
+	Err    error
+}
+
+func (e *HookError) Error() string {
+	return fmt.Sprintf("%s hook failed: %s", e.Hook, e.Err)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// joinHookErrors joins the errors of a failed hook and what failed afterwards into a single line,
+// either might be nil
+func joinHookErrors(hookErr error, err error) error {
+	switch {
+	case hookErr == nil:
+		return err
+	case err == nil:
+		return hookErr
+	default:
+		return fmt.Errorf("%w, %w", hookErr, err)
+	}
+}
+
+// runHook runs the command of the hook in the working tree, with the additional environment variables.
+// The hook is named after its phase.
+func (r *Repository) runHook(phase Phase, command string, env ...string) error {
+	if len(command) == 0 {
+		return nil
+	}
+
+	r.enterPhase(phase)
+
+	ctx, cancel := context.WithTimeout(context.Background(), HookTimeout)
+	defer cancel()
+
+	cmd := ShellCommand(ctx, command)
+	cmd.Dir = r.path
+	cmd.Env = append(os.Environ(), "TORTUGA_REPOSITORY="+r.Name, "TORTUGA_BRANCH="+r.Branch)
+	cmd.Env = append(cmd.Env, env...)
+
+	output, err := cmd.CombinedOutput()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s", HookTimeout)
+	}
+	if err != nil {
+		return &HookError{Hook: phase.String(), Output: output, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package repo_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benweidig/tortuga/git"
+	"github.com/benweidig/tortuga/git/gittest"
+	"github.com/benweidig/tortuga/repo"
+)
+
+func TestHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks are written for sh")
+	}
+
+	f := gittest.NewFixture(t)
+
+	t.Run("post-pull", func(t *testing.T) {
+		repoPath := f.Incoming("hook-post-pull", 1)
+		output := filepath.Join(t.TempDir(), "heads")
+
+		r := updatedRepository(t, repoPath, git.NewExecBackend())
+		r.Hooks.PostPull = `echo "$TORTUGA_OLD_HEAD $TORTUGA_NEW_HEAD" > ` + output
+		err := r.Sync(false)
+		if err != nil || r.State != repo.StateSynced {
+			t.Fatalf("state = %d, error = %v, want synced", r.State, err)
+		}
+
+		heads, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := r.SyncRecord.OldHead + " " + r.SyncRecord.NewHead
+		if strings.TrimSpace(string(heads)) != want {
+			t.Errorf("heads = %q, want %q", heads, want)
+		}
+	})
+
+	t.Run("post-pull without incoming", func(t *testing.T) {
+		repoPath := f.Outgoing("hook-post-pull-outgoing", 1)
+		output := filepath.Join(t.TempDir(), "ran")
+
+		r := updatedRepository(t, repoPath, git.NewExecBackend())
+		r.Hooks.PostPull = "touch " + output
+		r.Sync(false)
+
+		if _, err := os.Stat(output); err == nil {
+			t.Error("post-pull hook ran without incoming commits")
+		}
+	})
+
+	t.Run("pre-push failure", func(t *testing.T) {
+		repoPath := f.Outgoing("hook-pre-push", 1)
+
+		r := updatedRepository(t, repoPath, git.NewExecBackend())
+		r.Hooks.PrePush = "echo not today; exit 1"
+		err := r.Sync(false)
+		if err == nil || r.State != repo.StateHookFailed {
+			t.Fatalf("state = %d, error = %v, want hook failed", r.State, err)
+		}
+
+		var hookErr *repo.HookError
+		if !errors.As(err, &hookErr) || hookErr.Hook != "pre-push" || strings.TrimSpace(string(hookErr.Output)) != "not today" {
+			t.Errorf("error = %#v, want pre-push hook error with output", err)
+		}
+		if f.Head(repoPath, "HEAD") == f.Head(repoPath, "@{push}") {
+			t.Error("HEAD was pushed despite the failed hook")
+		}
+	})
+
+	t.Run("post-pull after pre-push failure", func(t *testing.T) {
+		repoPath := f.Outgoing("hook-pre-push-incoming", 1)
+		f.PushRemote("hook-pre-push-incoming", "incoming", "incoming\n")
+		f.WriteFile(repoPath, "README", "changed\n")
+		output := filepath.Join(t.TempDir(), "ran")
+
+		r := updatedRepository(t, repoPath, git.NewExecBackend())
+		r.Hooks.PrePush = "exit 1"
+		r.Hooks.PostPull = "touch " + output + "; exit 2"
+		r.Hooks.PostSync = "touch " + output + "-post-sync"
+		err := r.Sync(false)
+		if err == nil || r.State != repo.StateHookFailed {
+			t.Fatalf("state = %d, error = %v, want hook failed", r.State, err)
+		}
+
+		if want := "pre-push hook failed: exit status 1, post-pull hook failed: exit status 2"; err.Error() != want {
+			t.Errorf("error = %q, want %q", err, want)
+		}
+		if _, err := os.Stat(output); err != nil {
+			t.Error("post-pull hook didn't run after the pre-push hook failed")
+		}
+		if _, err := os.Stat(output + "-post-sync"); err == nil {
+			t.Error("post-sync hook ran after the sync failed")
+		}
+		if f.Git(repoPath, "status", "--porcelain", "--untracked-files=no") != "M README" {
+			t.Error("local changes weren't restored")
+		}
+		if len(r.SyncRecord.StashRef) == 0 {
+			t.Error("sync record has no stash")
+		}
+	})
+
+	t.Run("unstash conflict after pre-push failure", func(t *testing.T) {
+		repoPath := f.Outgoing("hook-pre-push-conflict", 1)
+		f.PushRemote("hook-pre-push-conflict", "README", "remote\n")
+		f.WriteFile(repoPath, "README", "local\n")
+
+		r := updatedRepository(t, repoPath, git.NewExecBackend())
+		r.Hooks.PrePush = "exit 1"
+		err := r.Sync(false)
+		if err == nil || r.State != repo.StateError {
+			t.Fatalf("state = %d, error = %v, want error", r.State, err)
+		}
+
+		if !strings.HasPrefix(err.Error(), "pre-push hook failed: exit status 1, ") {
+			t.Errorf("error = %q, want the hook and the unstash failure", err)
+		}
+		if len(r.SyncRecord.StashRef) == 0 {
+			t.Error("sync record has no stash")
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		repoPath := f.Outgoing("hook-timeout", 1)
+
+		timeout := repo.HookTimeout
+		repo.HookTimeout = 100 * time.Millisecond
+		defer func() {
+			repo.HookTimeout = timeout
+		}()
+
+		r := updatedRepository(t, repoPath, git.NewExecBackend())
+		r.Hooks.PrePush = "sleep 10"
+
+		started := time.Now()
+		err := r.Sync(false)
+		if err == nil || r.State != repo.StateHookFailed {
+			t.Fatalf("state = %d, error = %v, want hook failed", r.State, err)
+		}
+
+		if elapsed := time.Since(started); elapsed > 5*time.Second {
+			t.Errorf("sync took %s despite the timeout", elapsed)
+		}
+		if want := "pre-push hook failed: timed out after 100ms"; err.Error() != want {
+			t.Errorf("error = %q, want %q", err, want)
+		}
+	})
+
+	t.Run("post-sync failure", func(t *testing.T) {
+		repoPath := f.Dirty("hook-post-sync")
+		f.PushRemote("hook-post-sync", "incoming", "incoming\n")
+
+		r := updatedRepository(t, repoPath, git.NewExecBackend())
+		r.Hooks.PostSync = "exit 1"
+		err := r.Sync(false)
+		if err == nil || r.State != repo.StateHookFailed {
+			t.Fatalf("state = %d, error = %v, want hook failed", r.State, err)
+		}
+
+		if f.Head(repoPath, "HEAD") != f.Head(repoPath, "@{upstream}") {
+			t.Error("HEAD isn't at upstream despite the hook running after the sync")
+		}
+		if f.Git(repoPath, "status", "--porcelain", "--untracked-files=no") != "M README" {
+			t.Error("local changes weren't restored")
+		}
+	})
+}
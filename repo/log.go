@@ -0,0 +1,77 @@
+package repo
+
+import (
+	"sort"
+	"time"
+)
+
+// Commit represents a single commit of a Repository
+type Commit struct {
+	Repository string    `json:"repository"`
+	Branch     string    `json:"branch"`
+	Hash       string    `json:"hash"`
+	Author     string    `json:"author"`
+	Date       time.Time `json:"date"`
+	Subject    string    `json:"subject"`
+}
+
+// LogResult represents the gathered commits of a Repository
+type LogResult struct {
+	Commits []Commit
+	Error   error
+}
+
+// Log fetches the remote and gathers the commits of the current branch and its upstream,
+// optionally limited by date and author.
+func (r *Repository) Log(since string, author string) *LogResult {
+	revisions := []string{"HEAD"}
+
+	if len(r.Remote) > 0 {
+		err := r.withRetries(func() error {
+			return r.backend.Fetch(r.path, r.Remote, nil)
+		})
+		if err != nil {
+			return &LogResult{Error: err}
+		}
+		revisions = append(revisions, "@{upstream}")
+	}
+
+	gitCommits, err := r.backend.Log(r.path, since, author, revisions...)
+	if err != nil {
+		return &LogResult{Error: err}
+	}
+
+	result := &LogResult{
+		Commits: make([]Commit, len(gitCommits)),
+	}
+
+	for idx, c := range gitCommits {
+		result.Commits[idx] = Commit{
+			Repository: r.Name,
+			Branch:     r.Branch,
+			Hash:       c.Hash,
+			Author:     c.Author,
+			Date:       c.Date,
+			Subject:    c.Subject,
+		}
+	}
+
+	return result
+}
+
+// InterleaveCommits combines the commits of all results, newest first
+func InterleaveCommits(results []*LogResult) []Commit {
+	var commits []Commit
+	for _, result := range results {
+		if result == nil || result.Error != nil {
+			continue
+		}
+		commits = append(commits, result.Commits...)
+	}
+
+	sort.SliceStable(commits, func(i, j int) bool {
+		return commits[i].Date.After(commits[j].Date)
+	})
+
+	return commits
+}
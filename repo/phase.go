@@ -0,0 +1,117 @@
+package repo
+
+import (
+	"time"
+
+	"github.com/benweidig/tortuga/git"
+)
+
+// Phase is the step a Repository is currently working on during an Update or Sync
+type Phase int
+
+const (
+	// PhaseNone means no work is in progress
+	PhaseNone Phase = iota
+
+	// PhaseScanning is checking the working tree for changes
+	PhaseScanning
+
+	// PhaseFetching is fetching the remote
+	PhaseFetching
+
+	// PhaseComparing is counting incoming and outgoing commits
+	PhaseComparing
+
+	// PhaseStashing is stashing the local changes
+	PhaseStashing
+
+	// PhaseRebasing is rebasing onto the upstream branch
+	PhaseRebasing
+
+	// PhasePushing is pushing to the remote
+	PhasePushing
+
+	// PhaseUnstashing is restoring the stashed local changes
+	PhaseUnstashing
+
+	// PhasePrePush is running the pre-push hook
+	PhasePrePush
+
+	// PhasePostPull is running the post-pull hook
+	PhasePostPull
+
+	// PhasePostSync is running the post-sync hook
+	PhasePostSync
+)
+
+var phaseNames = map[Phase]string{
+	PhaseNone:       "",
+	PhaseScanning:   "scanning",
+	PhaseFetching:   "fetching",
+	PhaseComparing:  "comparing",
+	PhaseStashing:   "stashing",
+	PhaseRebasing:   "rebasing",
+	PhasePushing:    "pushing",
+	PhaseUnstashing: "unstashing",
+	PhasePrePush:    "pre-push",
+	PhasePostPull:   "post-pull",
+	PhasePostSync:   "post-sync",
+}
+
+func (p Phase) String() string {
+	return phaseNames[p]
+}
+
+// enterPhase starts the next phase of the work
+func (r *Repository) enterPhase(phase Phase) {
+	r.progressMtx.Lock()
+	defer r.progressMtx.Unlock()
+	r.phase = phase
+	r.phaseStarted = time.Now()
+}
+
+// setRetry sets the current retry attempt, 0 if not retrying
+func (r *Repository) setRetry(attempt int) {
+	r.progressMtx.Lock()
+	defer r.progressMtx.Unlock()
+	r.retry = attempt
+}
+
+// addDuration adds the time spent on a piece of work
+func (r *Repository) addDuration(d time.Duration) {
+	r.progressMtx.Lock()
+	defer r.progressMtx.Unlock()
+	r.duration += d
+}
+
+// Progress is a snapshot of the work on a Repository, see Repository.Progress
+type Progress struct {
+	// Phase is the phase currently worked on, PhaseNone if idle
+	Phase Phase
+
+	// PhaseStarted is when the current phase started
+	PhaseStarted time.Time
+
+	// Fetch is the progress of the current fetch, empty if not reported (yet)
+	Fetch git.FetchProgress
+
+	// Retry is the current retry attempt, 0 if not retrying
+	Retry int
+
+	// Duration is the time spent updating and syncing so far
+	Duration time.Duration
+}
+
+// Progress returns a snapshot of the work on the Repository.
+// It's safe to call while the Repository is worked on, e.g. to render it.
+func (r *Repository) Progress() Progress {
+	r.progressMtx.Lock()
+	defer r.progressMtx.Unlock()
+	return Progress{
+		Phase:        r.phase,
+		PhaseStarted: r.phaseStarted,
+		Fetch:        r.fetchProgress,
+		Retry:        r.retry,
+		Duration:     r.duration,
+	}
+}
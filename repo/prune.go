@@ -0,0 +1,107 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PruneReason describes why a local branch can be pruned
+type PruneReason int
+
+const (
+	// PruneReasonMerged means the branch is merged into the default branch
+	PruneReasonMerged PruneReason = iota
+
+	// PruneReasonSquashed means the changes of the branch are contained in the default branch, e.g. via squash merge
+	PruneReasonSquashed
+
+	// PruneReasonGone means the upstream of the branch was deleted
+	PruneReasonGone
+)
+
+// PrunableBranch is a local branch that can be deleted
+type PrunableBranch struct {
+	Name   string
+	Reason PruneReason
+}
+
+// PruneResult represents the prunable branches of a Repository
+type PruneResult struct {
+	Branches []PrunableBranch
+	Deleted  bool
+	Error    error
+}
+
+// FindPrunableBranches fetches the remote with pruning, and finds all local branches that are
+// either merged into the default branch, or whose upstream is gone.
+// The current branch and the default branch itself are never included.
+func (r *Repository) FindPrunableBranches() *PruneResult {
+	remote := r.remoteName()
+
+	err := r.withRetries(func() error {
+		return r.backend.FetchPrune(r.path, remote)
+	})
+	if err != nil {
+		return &PruneResult{Error: err}
+	}
+
+	defaultBranch, err := r.backend.DefaultBranch(r.path, remote)
+	if err != nil {
+		return &PruneResult{Error: fmt.Errorf("no default branch: %w", err)}
+	}
+
+	localBranches, err := r.backend.LocalBranches(r.path)
+	if err != nil {
+		return &PruneResult{Error: err}
+	}
+
+	mergedBranches, err := r.backend.MergedBranches(r.path, defaultBranch)
+	if err != nil {
+		return &PruneResult{Error: err}
+	}
+
+	merged := make(map[string]bool, len(mergedBranches))
+	for _, branch := range mergedBranches {
+		merged[branch] = true
+	}
+
+	result := &PruneResult{}
+
+	for _, branch := range localBranches {
+		if branch.Name == r.Branch || branch.Name == strings.TrimPrefix(defaultBranch, remote+"/") {
+			continue
+		}
+
+		switch {
+		case branch.UpstreamGone:
+			result.Branches = append(result.Branches, PrunableBranch{branch.Name, PruneReasonGone})
+
+		case merged[branch.Name]:
+			result.Branches = append(result.Branches, PrunableBranch{branch.Name, PruneReasonMerged})
+
+		default:
+			// A branch that can't be compared, e.g. due to unrelated history, isn't pruned
+			squashed, err := r.backend.IsSquashMerged(r.path, branch.Name, defaultBranch)
+			if err == nil && squashed {
+				result.Branches = append(result.Branches, PrunableBranch{branch.Name, PruneReasonSquashed})
+			}
+		}
+	}
+
+	return result
+}
+
+// DeleteBranches deletes all prunable branches of the result
+func (r *Repository) DeleteBranches(result *PruneResult) error {
+	for _, branch := range result.Branches {
+		err := r.backend.DeleteBranch(r.path, branch.Name)
+		if err != nil {
+			result.Error = err
+			return err
+		}
+	}
+
+	result.Deleted = true
+
+	return nil
+}
@@ -1,16 +1,18 @@
 package repo
 
 import (
-	"bufio"
 	"path"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/benweidig/tortuga/git"
 )
 
 // Repository represents Git repository, but only the currently checked out branch
 type Repository struct {
-	path string
+	path    string
+	backend git.Backend
 
 	Name   string
 	Branch string
@@ -24,30 +26,86 @@ type Repository struct {
 	Changes     int
 	Unversioned int
 
+	// LastCommit is the committer date of HEAD
+	LastCommit time.Time
+
+	// RemoteHost is the host of the remote, empty if there's none or it's local
+	RemoteHost string
+
+	// Tags are the configured tags of the repository, for grouping
+	Tags []string
+
+	// UpstreamBranch is the name of the upstream branch, e.g. "origin/main"
+	UpstreamBranch string
+
+	// Details are the expensive details to gather when updating
+	Details Detail
+
+	// LastFetch is when the repository was fetched the last time before updating it,
+	// only gathered with DetailLastFetch
+	LastFetch time.Time
+
+	// Stashes is the count of stash entries, only gathered with DetailStashes
+	Stashes int
+
+	// Size is the size on disk in bytes, only gathered with DetailSize
+	Size int64
+
+	SyncRecord *SyncRecord
+
+	// MaxRetries of transiently failed fetches and pushes
+	MaxRetries int
+
+	// OnRetry is called before each retry attempt, e.g. to render it. Might be nil.
+	OnRetry func()
+
+	// Hooks run while syncing
+	Hooks Hooks
+
 	stashed bool
+
+	// progressMtx guards the progress, which changes while the Repository is worked on and rendered,
+	// see Progress
+	progressMtx   sync.Mutex
+	phase         Phase
+	phaseStarted  time.Time
+	fetchProgress git.FetchProgress
+	retry         int
+	duration      time.Duration
 }
 
+// RetryBackoff is the delay before the first retry, doubled for each further attempt
+var RetryBackoff = 2 * time.Second
+
 // NewRepository creates a bare Repository construct containing the minimum for initial display
 func NewRepository(repoPath string) (*Repository, error) {
+	return NewRepositoryWithBackend(repoPath, git.NewExecBackend())
+}
+
+// NewRepositoryWithBackend creates a bare Repository construct like NewRepository,
+// but all git operations are run by the provided Backend
+func NewRepositoryWithBackend(repoPath string, backend git.Backend) (*Repository, error) {
 	r := &Repository{
-		Name:  path.Base(repoPath),
-		path:  repoPath,
-		State: StateNone,
+		Name:    path.Base(repoPath),
+		path:    repoPath,
+		backend: backend,
+		State:   StateNone,
 	}
 
-	branch, err := git.LocalBranch(r.path)
+	branch, err := r.backend.LocalBranch(r.path)
 	if err != nil {
 		r.withError(err).Branch = "???"
 		return r, err
 	}
 	r.Branch = branch
 
-	upstreamBranch, err := git.UpstreamBranch(r.path)
+	upstreamBranch, err := r.backend.UpstreamBranch(r.path)
 	if err != nil {
 		r.withError(err)
 		return r, err
 	}
 	r.Remote = strings.Split(upstreamBranch, "/")[0]
+	r.UpstreamBranch = upstreamBranch
 
 	return r, nil
 }
@@ -63,96 +121,171 @@ func (r *Repository) withError(err error) *Repository {
 
 // Update analyzes the current working tree and fetches remote changes
 func (r *Repository) Update() error {
+	return r.update(r.fetch)
+}
+
+// update analyzes the current working tree and fetches remote changes with the provided fetch
+func (r *Repository) update(fetch func() error) error {
 	if r.State == StateError {
 		return nil
 	}
-	status, err := git.Status(r.path)
+
+	started := time.Now()
+	defer func() {
+		r.addDuration(time.Since(started))
+		r.enterPhase(PhaseNone)
+	}()
+
+	r.enterPhase(PhaseScanning)
+	err := r.updateChanges()
 	if err != nil {
 		return r.withError(err).Error
 	}
-	scanner := bufio.NewScanner(&status)
-
-	for scanner.Scan() {
-		row := scanner.Text()
-		if len(row) < 3 {
-			continue
-		}
 
-		status := strings.TrimSpace(row[0:3])
-		if len(status) == 0 {
-			continue
-		}
+	// Fetching rewrites FETCH_HEAD even without any changes
+	r.updateLastFetch()
 
-		switch status[0] {
-		case 'M', 'T', 'A', 'D', 'R', 'C', 'U':
-			r.Changes++
-		case '?':
-			r.Unversioned++
-		}
+	r.enterPhase(PhaseFetching)
+	err = fetch()
+	if err != nil {
+		return r.withError(err).Error
 	}
 
-	err = git.Fetch(r.path, r.Remote)
+	r.enterPhase(PhaseComparing)
+	err = r.updateCounts()
 	if err != nil {
 		return r.withError(err).Error
 	}
 
-	incoming, err := git.Incoming(r.path, r.Branch)
+	r.updateDetails()
+
+	r.State = StateRemoteFetched
+
+	return nil
+}
+
+// Refresh analyzes the current working tree against the already fetched remote state
+func (r *Repository) Refresh() error {
+	if r.State == StateError {
+		return nil
+	}
+
+	defer r.enterPhase(PhaseNone)
+
+	r.enterPhase(PhaseScanning)
+	err := r.updateChanges()
 	if err != nil {
 		return r.withError(err).Error
 	}
-	r.Incoming = incoming
 
-	outgoing, err := git.Outgoing(r.path, r.Branch)
+	r.updateLastFetch()
+
+	r.enterPhase(PhaseComparing)
+	err = r.updateCounts()
 	if err != nil {
 		return r.withError(err).Error
 	}
-	r.Outgoing = outgoing
+
+	r.updateDetails()
 
 	r.State = StateRemoteFetched
 
 	return nil
 }
 
-// Sync stashes, rebases, pushs and unstashes the Repository
+// Sync stashes, rebases, pushs and unstashes the Repository, and runs the hooks in between
 func (r *Repository) Sync(incomingOnly bool) error {
 	if r.State == StateError {
 		return nil
 	}
 
+	started := time.Now()
+	record := &SyncRecord{}
+	r.SyncRecord = record
+
+	// Whatever happens, the record should reflect the final HEAD
+	defer func() {
+		record.NewHead, _ = r.backend.RevParse(r.path, "HEAD")
+		record.Duration = time.Since(started)
+		r.addDuration(record.Duration)
+		r.enterPhase(PhaseNone)
+	}()
+
+	record.OldHead, _ = r.backend.RevParse(r.path, "HEAD")
+
 	errorReturn := func(err error) error {
 		if r.stashed {
-			git.StashPop(r.path)
+			r.backend.StashPop(r.path)
 		}
 		return r.withError(err).Error
 	}
 
 	if r.Changes > 0 {
-		err := git.StashSave(r.path)
+		r.enterPhase(PhaseStashing)
+		err := r.backend.StashSave(r.path)
 		if err != nil {
 			return errorReturn(err)
 		}
 		r.stashed = true
+		record.StashRef, _ = r.backend.RevParse(r.path, "stash@{0}")
 	}
 
 	if r.Incoming > 0 {
-		err := git.Rebase(r.path)
+		r.enterPhase(PhaseRebasing)
+		err := r.backend.Rebase(r.path)
 		if err != nil {
 			return errorReturn(err)
 		}
 	}
 
+	// A failed hook doesn't undo what's done so far, the remaining steps are still done,
+	// except pushing after a failed pre-push hook, and the post-sync hook
+	var hookErr error
+
 	if !incomingOnly && r.Outgoing > 0 {
-		err := git.Push(r.path)
+		hookErr = r.runHook(PhasePrePush, r.Hooks.PrePush)
+	}
+
+	if !incomingOnly && r.Outgoing > 0 && hookErr == nil {
+		r.enterPhase(PhasePushing)
+		pushBase, _ := r.backend.RevParse(r.path, "@{push}")
+		pushHead, _ := r.backend.RevParse(r.path, "HEAD")
+
+		err := r.withRetries(func() error {
+			return r.backend.Push(r.path)
+		})
 		if err != nil {
 			return errorReturn(err)
 		}
+		record.PushedRange = pushBase + ".." + pushHead
 	}
 
 	if r.stashed {
-		err := git.StashPop(r.path)
+		r.enterPhase(PhaseUnstashing)
+		err := r.backend.StashPop(r.path)
 		if err != nil {
-			return r.withError(err).Error
+			return r.withError(joinHookErrors(hookErr, err)).Error
 		}
+		r.stashed = false
+	}
+
+	// Only applied incoming commits are pulled
+	if r.Incoming > 0 && len(r.Hooks.PostPull) > 0 {
+		newHead, _ := r.backend.RevParse(r.path, "HEAD")
+		if newHead != record.OldHead {
+			err := r.runHook(PhasePostPull, r.Hooks.PostPull, "TORTUGA_OLD_HEAD="+record.OldHead, "TORTUGA_NEW_HEAD="+newHead)
+			hookErr = joinHookErrors(hookErr, err)
+		}
+	}
+
+	if hookErr == nil {
+		hookErr = r.runHook(PhasePostSync, r.Hooks.PostSync)
+	}
+
+	if hookErr != nil {
+		r.State = StateHookFailed
+		r.Error = hookErr
+		return hookErr
 	}
 
 	r.State = StateSynced
@@ -165,6 +298,126 @@ func (r *Repository) NeedsSync() bool {
 	return r.Incoming > 0 || r.Outgoing > 0
 }
 
+// fetch fetches the remote of the upstream branch, while keeping track of its progress
+func (r *Repository) fetch() error {
+	defer r.setFetchProgress(git.FetchProgress{})
+
+	return r.withRetries(func() error {
+		return r.backend.Fetch(r.path, r.Remote, r.setFetchProgress)
+	})
+}
+
+func (r *Repository) setFetchProgress(progress git.FetchProgress) {
+	r.progressMtx.Lock()
+	defer r.progressMtx.Unlock()
+	r.fetchProgress = progress
+}
+
+// withRetries runs the network operation, and retries it with exponential backoff
+// as long as it fails transiently
+func (r *Repository) withRetries(fn func() error) error {
+	err := fn()
+
+	for attempt := 1; err != nil && attempt <= r.MaxRetries && git.IsTransientError(err); attempt++ {
+		r.setRetry(attempt)
+		if r.OnRetry != nil {
+			r.OnRetry()
+		}
+
+		time.Sleep(RetryBackoff << (attempt - 1))
+		err = fn()
+	}
+
+	r.setRetry(0)
+
+	return err
+}
+
+// Path returns the path of the working tree
+func (r *Repository) Path() string {
+	return r.path
+}
+
+// remoteName returns the remote of the upstream branch, or the conventional "origin" if there's none
+func (r *Repository) remoteName() string {
+	if len(r.Remote) == 0 {
+		return "origin"
+	}
+	return r.Remote
+}
+
+// updateCounts counts the incoming and outgoing commits
+func (r *Repository) updateCounts() error {
+	incoming, err := r.backend.Incoming(r.path, r.Branch)
+	if err != nil {
+		return err
+	}
+	r.Incoming = incoming
+
+	outgoing, err := r.backend.Outgoing(r.path, r.Branch)
+	if err != nil {
+		return err
+	}
+	r.Outgoing = outgoing
+
+	return nil
+}
+
+// updateDetails gathers the details only needed for display. They are optional,
+// so failing to determinate them isn't an error of the repository.
+func (r *Repository) updateDetails() {
+	lastCommit, err := r.backend.CommitDate(r.path, "HEAD")
+	if err == nil {
+		r.LastCommit = lastCommit
+	}
+
+	if len(r.Remote) > 0 && len(r.RemoteHost) == 0 {
+		remoteURL, err := r.backend.RemoteURL(r.path, r.Remote)
+		if err == nil {
+			r.RemoteHost = git.RemoteHost(remoteURL)
+		}
+	}
+
+	if r.Details&DetailStashes != 0 {
+		stashes, err := r.backend.StashCount(r.path)
+		if err == nil {
+			r.Stashes = stashes
+		}
+	}
+
+	if r.Details&DetailSize != 0 {
+		size, err := diskUsage(r.path)
+		if err == nil {
+			r.Size = size
+		}
+	}
+}
+
+// updateLastFetch gathers when the repository was fetched the last time, if requested
+func (r *Repository) updateLastFetch() {
+	if r.Details&DetailLastFetch == 0 {
+		return
+	}
+
+	lastFetch, err := r.backend.LastFetch(r.path)
+	if err == nil {
+		r.LastFetch = lastFetch
+	}
+}
+
+// updateChanges counts the changed and unversioned files of the working tree
+func (r *Repository) updateChanges() error {
+	counts, err := r.backend.Status(r.path)
+	if err != nil {
+		return err
+	}
+
+	r.Changes = counts.Changes
+	r.Unversioned = counts.Unversioned
+
+	return nil
+}
+
 // ErrorCount return the total count of repositories with errors
 func ErrorCount(r []*Repository) int {
 	count := 0
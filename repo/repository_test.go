@@ -0,0 +1,443 @@
+package repo_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benweidig/tortuga/git"
+	"github.com/benweidig/tortuga/git/gittest"
+	"github.com/benweidig/tortuga/repo"
+)
+
+func TestMain(m *testing.M) {
+	// The user's config mustn't interfere, but rebasing creates commits, which needs an identity
+	os.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	os.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	os.Setenv("GIT_AUTHOR_NAME", "Tortuga")
+	os.Setenv("GIT_AUTHOR_EMAIL", "tortuga@example.com")
+	os.Setenv("GIT_COMMITTER_NAME", "Tortuga")
+	os.Setenv("GIT_COMMITTER_EMAIL", "tortuga@example.com")
+
+	os.Exit(m.Run())
+}
+
+func TestUpdate(t *testing.T) {
+	backends := []struct {
+		name    string
+		backend git.Backend
+	}{
+		{"exec", git.NewExecBackend()},
+		{"go-git", git.NewGoGitBackend()},
+	}
+
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			testUpdate(t, b.backend)
+		})
+	}
+}
+
+// testUpdate updates all scenarios with the backend, so all backends must agree on them
+func testUpdate(t *testing.T, backend git.Backend) {
+	f := gittest.NewFixture(t)
+
+	tests := []struct {
+		name        string
+		repoPath    string
+		state       repo.State
+		errMsg      string
+		incoming    int
+		outgoing    int
+		changes     int
+		unversioned int
+	}{
+		{"up-to-date", f.UpToDate("up-to-date"), repo.StateRemoteFetched, "", 0, 0, 0, 0},
+		{"incoming", f.Incoming("incoming", 2), repo.StateRemoteFetched, "", 2, 0, 0, 0},
+		{"outgoing", f.Outgoing("outgoing", 3), repo.StateRemoteFetched, "", 0, 3, 0, 0},
+		{"conflict", f.Conflict("conflict"), repo.StateRemoteFetched, "", 1, 1, 0, 0},
+		{"dirty", f.Dirty("dirty"), repo.StateRemoteFetched, "", 0, 0, 1, 1},
+		{"no-upstream", f.NoUpstream("no-upstream"), repo.StateError, "no upstream", 0, 0, 0, 0},
+		{"detached", f.Detached("detached"), repo.StateError, "not on a branch", 0, 0, 0, 0},
+		{"worktree", f.Worktree("worktree"), repo.StateRemoteFetched, "", 0, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := repo.NewRepositoryWithBackend(tt.repoPath, backend)
+			r.Update()
+
+			if r.State != tt.state {
+				t.Fatalf("state = %d, want %d (error: %v)", r.State, tt.state, r.Error)
+			}
+			if len(tt.errMsg) > 0 && r.Error.Error() != tt.errMsg {
+				t.Errorf("error = %q, want %q", r.Error, tt.errMsg)
+			}
+			if r.Incoming != tt.incoming || r.Outgoing != tt.outgoing {
+				t.Errorf("incoming/outgoing = %d/%d, want %d/%d", r.Incoming, r.Outgoing, tt.incoming, tt.outgoing)
+			}
+			if r.Changes != tt.changes || r.Unversioned != tt.unversioned {
+				t.Errorf("changes/unversioned = %d/%d, want %d/%d", r.Changes, r.Unversioned, tt.changes, tt.unversioned)
+			}
+		})
+	}
+}
+
+func TestSync(t *testing.T) {
+	f := gittest.NewFixture(t)
+
+	t.Run("incoming", func(t *testing.T) {
+		repoPath := f.Incoming("sync-incoming", 2)
+
+		r := updatedRepository(t, repoPath, git.NewExecBackend())
+		err := r.Sync(false)
+		if err != nil || r.State != repo.StateSynced {
+			t.Fatalf("state = %d, error = %v, want synced", r.State, err)
+		}
+
+		if f.Head(repoPath, "HEAD") != f.Head(repoPath, "@{upstream}") {
+			t.Error("HEAD isn't at upstream after sync")
+		}
+		if r.SyncRecord.OldHead == r.SyncRecord.NewHead {
+			t.Error("sync record has unchanged HEAD")
+		}
+	})
+
+	t.Run("outgoing", func(t *testing.T) {
+		repoPath := f.Outgoing("sync-outgoing", 2)
+
+		r := updatedRepository(t, repoPath, git.NewExecBackend())
+		err := r.Sync(false)
+		if err != nil || r.State != repo.StateSynced {
+			t.Fatalf("state = %d, error = %v, want synced", r.State, err)
+		}
+
+		if f.Head(repoPath, "HEAD") != f.Head(repoPath, "@{push}") {
+			t.Error("HEAD wasn't pushed")
+		}
+		if len(r.SyncRecord.PushedRange) == 0 {
+			t.Error("sync record has no pushed range")
+		}
+	})
+
+	t.Run("incoming only", func(t *testing.T) {
+		repoPath := f.Outgoing("sync-incoming-only", 1)
+
+		r := updatedRepository(t, repoPath, git.NewExecBackend())
+		r.Sync(true)
+
+		if f.Head(repoPath, "HEAD") == f.Head(repoPath, "@{push}") {
+			t.Error("HEAD was pushed")
+		}
+	})
+
+	t.Run("dirty with incoming", func(t *testing.T) {
+		repoPath := f.Dirty("sync-dirty")
+		f.PushRemote("sync-dirty", "incoming", "incoming\n")
+
+		r := updatedRepository(t, repoPath, git.NewExecBackend())
+		err := r.Sync(false)
+		if err != nil || r.State != repo.StateSynced {
+			t.Fatalf("state = %d, error = %v, want synced", r.State, err)
+		}
+
+		if len(r.SyncRecord.StashRef) == 0 {
+			t.Error("sync record has no stash")
+		}
+		if f.Git(repoPath, "status", "--porcelain", "--untracked-files=no") != "M README" {
+			t.Error("local changes weren't restored")
+		}
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		repoPath := f.Conflict("sync-conflict")
+
+		r := updatedRepository(t, repoPath, git.NewExecBackend())
+		err := r.Sync(false)
+		if err == nil || r.State != repo.StateError {
+			t.Fatalf("state = %d, error = %v, want error", r.State, err)
+		}
+	})
+}
+
+func TestLastFetch(t *testing.T) {
+	f := gittest.NewFixture(t)
+
+	// FETCH_HEAD is written by every fetch, so it has to be an hour old before updating
+	lastFetch := time.Now().Add(-time.Hour).Truncate(time.Second)
+	fetchedBefore := func(repoPath string) {
+		f.Git(repoPath, "fetch", "-q")
+		err := os.Chtimes(filepath.Join(repoPath, ".git", "FETCH_HEAD"), lastFetch, lastFetch)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("update", func(t *testing.T) {
+		repoPath := f.Incoming("last-fetch", 1)
+		fetchedBefore(repoPath)
+
+		r, _ := repo.NewRepository(repoPath)
+		r.Details = repo.DetailLastFetch
+		r.Update()
+
+		if !r.LastFetch.Equal(lastFetch) {
+			t.Errorf("last fetch = %v, want %v", r.LastFetch, lastFetch)
+		}
+	})
+
+	t.Run("group", func(t *testing.T) {
+		worktreePath := f.Worktree("last-fetch-worktree")
+		clonePath := filepath.Join(f.Dir, "last-fetch-worktree")
+		fetchedBefore(clonePath)
+
+		var repos []*repo.Repository
+		for _, repoPath := range []string{clonePath, worktreePath} {
+			r, _ := repo.NewRepository(repoPath)
+			r.Details = repo.DetailLastFetch
+			repos = append(repos, r)
+		}
+
+		group := repo.GroupRepositories(repos)[0]
+		for _, r := range group.Repositories {
+			group.Update(r)
+
+			if !r.LastFetch.Equal(lastFetch) {
+				t.Errorf("%s: last fetch = %v, want %v", r.Name, r.LastFetch, lastFetch)
+			}
+		}
+	})
+}
+
+func TestBackendFailures(t *testing.T) {
+	f := gittest.NewFixture(t)
+
+	t.Run("fetch auth failure", func(t *testing.T) {
+		backend := gittest.NewFakeBackend().Fail(gittest.OpFetch, gittest.AuthError())
+
+		r, _ := repo.NewRepositoryWithBackend(f.Incoming("auth", 1), backend)
+		r.Update()
+
+		if r.State != repo.StateError || r.Error.Error() != "auth error" {
+			t.Errorf("state = %d, error = %v, want auth error", r.State, r.Error)
+		}
+	})
+
+	t.Run("fetch timeout", func(t *testing.T) {
+		backend := gittest.NewFakeBackend().Fail(gittest.OpFetch, gittest.TimeoutError())
+
+		r, _ := repo.NewRepositoryWithBackend(f.Incoming("timeout", 1), backend)
+		r.Update()
+
+		if r.State != repo.StateError {
+			t.Errorf("state = %d, want error", r.State)
+		}
+	})
+
+	t.Run("push rejected unstashes", func(t *testing.T) {
+		repoPath := f.Outgoing("rejected", 1)
+		f.WriteFile(repoPath, "README", "changed\n")
+
+		backend := gittest.NewFakeBackend().Fail(gittest.OpPush, gittest.PushRejectedError())
+
+		r := updatedRepository(t, repoPath, backend)
+		err := r.Sync(false)
+		if err == nil || r.State != repo.StateError {
+			t.Fatalf("state = %d, error = %v, want error", r.State, err)
+		}
+
+		calls := backend.Calls()
+		if calls[len(calls)-2] != gittest.OpStashPop {
+			t.Errorf("calls = %v, want stash pop after failed push", calls)
+		}
+		if f.Git(repoPath, "stash", "list") != "" {
+			t.Error("stash wasn't popped")
+		}
+	})
+}
+
+func TestCheckout(t *testing.T) {
+	f := gittest.NewFixture(t)
+	repoPath := f.UpToDate("checkout")
+	f.Git(repoPath, "push", "-q", "origin", gittest.DefaultBranch+":feature")
+	f.Git(repoPath, "fetch", "-q")
+
+	r, _ := repo.NewRepository(repoPath)
+
+	result := r.Checkout("feature", false, false)
+	if result.State != repo.CheckoutStateSwitched {
+		t.Fatalf("state = %d, error = %v, want switched", result.State, result.Error)
+	}
+	if r.Branch != gittest.DefaultBranch {
+		t.Errorf("branch = %s before applying the checkout, want %s", r.Branch, gittest.DefaultBranch)
+	}
+
+	r.ApplyCheckout(result)
+	if r.Branch != "feature" || r.UpstreamBranch != "origin/feature" || r.Remote != "origin" {
+		t.Errorf("branch/upstream/remote = %s/%s/%s, want feature/origin/feature/origin", r.Branch, r.UpstreamBranch, r.Remote)
+	}
+
+	// A new branch has no upstream
+	result = r.CreateBranch("local", "", false)
+	r.ApplyCheckout(result)
+	if r.Branch != "local" || len(r.UpstreamBranch) > 0 || len(r.Remote) > 0 {
+		t.Errorf("branch/upstream/remote = %s/%s/%s, want local without upstream", r.Branch, r.UpstreamBranch, r.Remote)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	f := gittest.NewFixture(t)
+	repoPath := f.UpToDate("prune")
+	f.Git(repoPath, "branch", "merged")
+
+	// Unrelated history has no merge base, which mustn't fail the other branches
+	f.Git(repoPath, "checkout", "-q", "--orphan", "unrelated")
+	f.Commit(repoPath, "unrelated", "unrelated\n")
+	f.Git(repoPath, "checkout", "-q", gittest.DefaultBranch)
+
+	r, _ := repo.NewRepository(repoPath)
+
+	result := r.FindPrunableBranches()
+	if result.Error != nil {
+		t.Fatal(result.Error)
+	}
+	if len(result.Branches) != 1 || result.Branches[0].Name != "merged" {
+		t.Errorf("branches = %v, want only merged", result.Branches)
+	}
+}
+
+func TestCommandBackendFailures(t *testing.T) {
+	f := gittest.NewFixture(t)
+	repoPath := f.Outgoing("commands", 1)
+
+	failing := func(op string) *repo.Repository {
+		r, err := repo.NewRepositoryWithBackend(repoPath, gittest.NewFakeBackend().Fail(op, gittest.AuthError()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+
+	t.Run("create branch", func(t *testing.T) {
+		result := failing(gittest.OpCreateBranch).Checkout("other", true, false)
+		if result.State != repo.CheckoutStateError {
+			t.Errorf("state = %d, want error", result.State)
+		}
+		if f.Git(repoPath, "branch", "--show-current") != gittest.DefaultBranch {
+			t.Error("branch was switched")
+		}
+	})
+
+	t.Run("checkout default branch", func(t *testing.T) {
+		result := failing(gittest.OpDefaultBranch).Checkout("other", true, false)
+		if result.Error == nil || result.Error.Error() != "no default branch: auth error" {
+			t.Errorf("error = %v, want no default branch with its cause", result.Error)
+		}
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		result := failing(gittest.OpFetchPrune).FindPrunableBranches()
+		if result.Error == nil {
+			t.Error("pruning didn't fail")
+		}
+	})
+
+	t.Run("prune default branch", func(t *testing.T) {
+		result := failing(gittest.OpDefaultBranch).FindPrunableBranches()
+		if result.Error == nil || result.Error.Error() != "no default branch: auth error" {
+			t.Errorf("error = %v, want no default branch with its cause", result.Error)
+		}
+	})
+
+	t.Run("log", func(t *testing.T) {
+		result := failing(gittest.OpLog).Log("", "")
+		if result.Error == nil {
+			t.Error("log didn't fail")
+		}
+	})
+
+	t.Run("grep", func(t *testing.T) {
+		result := failing(gittest.OpGrep).Grep("initial", false)
+		if result.Error == nil {
+			t.Error("grep didn't fail")
+		}
+	})
+
+	t.Run("undo", func(t *testing.T) {
+		oldHead := f.Head(repoPath, "HEAD~1")
+		result := failing(gittest.OpResetKeep).Undo(gittest.DefaultBranch, oldHead)
+		if result.State != repo.UndoStateError {
+			t.Errorf("state = %d, want error", result.State)
+		}
+		if f.Head(repoPath, "HEAD") == oldHead {
+			t.Error("HEAD was reset")
+		}
+	})
+}
+
+func TestRetries(t *testing.T) {
+	f := gittest.NewFixture(t)
+
+	backoff := repo.RetryBackoff
+	repo.RetryBackoff = time.Millisecond
+	t.Cleanup(func() {
+		repo.RetryBackoff = backoff
+	})
+
+	tests := []struct {
+		name       string
+		maxRetries int
+		errs       []error
+		state      repo.State
+		retries    int
+	}{
+		{"transient recovered", 2, []error{gittest.TimeoutError(), gittest.TimeoutError()}, repo.StateRemoteFetched, 2},
+		{"transient exhausted", 1, []error{gittest.TimeoutError(), gittest.TimeoutError()}, repo.StateError, 1},
+		{"not transient", 2, []error{gittest.AuthError()}, repo.StateError, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := gittest.NewFakeBackend().Fail(gittest.OpFetch, tt.errs...)
+
+			r, _ := repo.NewRepositoryWithBackend(f.Incoming(strings.ReplaceAll(tt.name, " ", "-"), 1), backend)
+			r.MaxRetries = tt.maxRetries
+
+			retries := 0
+			r.OnRetry = func() {
+				retries++
+			}
+
+			r.Update()
+
+			if r.State != tt.state {
+				t.Errorf("state = %d, want %d (error: %v)", r.State, tt.state, r.Error)
+			}
+			if retries != tt.retries {
+				t.Errorf("retries = %d, want %d", retries, tt.retries)
+			}
+			if retry := r.Progress().Retry; retry != 0 {
+				t.Errorf("retry = %d after finishing, want 0", retry)
+			}
+		})
+	}
+}
+
+// updatedRepository creates and updates a Repository, which must not fail
+func updatedRepository(t *testing.T, repoPath string, backend git.Backend) *repo.Repository {
+	t.Helper()
+
+	r, err := repo.NewRepositoryWithBackend(repoPath, backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
@@ -21,4 +21,7 @@ const (
 
 	// StateError indicates any kind of error, the Repository shouldn't do any more actions
 	StateError
+
+	// StateHookFailed means a hook failed while syncing, so the Repository was synced only up to the hook
+	StateHookFailed
 )
@@ -0,0 +1,54 @@
+package repo
+
+// Summary holds the totals of multiple repositories
+type Summary struct {
+	Repositories int
+	UpToDate     int
+	NeedsSync    int
+	Synced       int
+	Errors       int
+
+	// Skipped are synced repositories whose outgoing commits weren't pushed due to syncing incoming only
+	Skipped int
+
+	// HookFailures are repositories with a failed hook while syncing
+	HookFailures int
+
+	Incoming int
+	Outgoing int
+}
+
+// Summarize counts the repositories by their state, and totals their incoming and outgoing commits.
+// Repositories still pending are only included in the total.
+func Summarize(repos []*Repository, incomingOnly bool) Summary {
+	s := Summary{
+		Repositories: len(repos),
+		Errors:       ErrorCount(repos),
+	}
+
+	for _, r := range repos {
+		s.Incoming += r.Incoming
+		s.Outgoing += r.Outgoing
+
+		switch r.State {
+		case StateRemoteFetched, StateNeedsSync, StateNoSyncNeeded:
+			if r.NeedsSync() {
+				s.NeedsSync++
+			} else {
+				s.UpToDate++
+			}
+
+		case StateSynced:
+			if r.Incoming == 0 && incomingOnly {
+				s.Skipped++
+			} else {
+				s.Synced++
+			}
+
+		case StateHookFailed:
+			s.HookFailures++
+		}
+	}
+
+	return s
+}
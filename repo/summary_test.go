@@ -0,0 +1,38 @@
+package repo_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/benweidig/tortuga/repo"
+)
+
+func TestSummarize(t *testing.T) {
+	repos := []*repo.Repository{
+		{State: repo.StateNone},
+		{State: repo.StateRemoteFetched},
+		{State: repo.StateRemoteFetched, Incoming: 2},
+		{State: repo.StateSynced, Incoming: 1, Outgoing: 3},
+		{State: repo.StateSynced, Outgoing: 1},
+		{State: repo.StateError, Error: errors.New("error")},
+		{State: repo.StateHookFailed, Error: errors.New("post-sync hook failed: exit status 1")},
+	}
+
+	tests := []struct {
+		name         string
+		incomingOnly bool
+		want         repo.Summary
+	}{
+		{"full", false, repo.Summary{Repositories: 7, UpToDate: 1, NeedsSync: 1, Synced: 2, Errors: 1, HookFailures: 1, Incoming: 3, Outgoing: 4}},
+		{"incoming-only", true, repo.Summary{Repositories: 7, UpToDate: 1, NeedsSync: 1, Synced: 1, Errors: 1, Skipped: 1, HookFailures: 1, Incoming: 3, Outgoing: 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := repo.Summarize(repos, tt.incomingOnly)
+			if got != tt.want {
+				t.Errorf("summary = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
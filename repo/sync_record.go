@@ -0,0 +1,12 @@
+package repo
+
+import "time"
+
+// SyncRecord holds what a Sync did to a Repository, so it can be reviewed or undone later
+type SyncRecord struct {
+	OldHead     string
+	NewHead     string
+	PushedRange string
+	StashRef    string
+	Duration    time.Duration
+}
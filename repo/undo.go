@@ -0,0 +1,67 @@
+package repo
+
+// UndoState represents the outcome of undoing a sync of a Repository
+type UndoState int
+
+const (
+	// UndoStateReset means the branch was reset to its HEAD before the sync
+	UndoStateReset UndoState = iota
+
+	// UndoStateUnchanged means the HEAD is already the one before the sync
+	UndoStateUnchanged
+
+	// UndoStateBranchChanged means a different branch is checked out than during the sync
+	UndoStateBranchChanged
+
+	// UndoStateNotInReflog means the HEAD before the sync isn't known to the reflog anymore
+	UndoStateNotInReflog
+
+	// UndoStateError indicates any kind of error
+	UndoStateError
+)
+
+// UndoResult represents the outcome of undoing a sync of a Repository
+type UndoResult struct {
+	State UndoState
+	Error error
+}
+
+// Undo resets the branch to the HEAD before a sync, if it's still checked out
+// and the old HEAD is part of the reflog. Local changes are kept.
+// Pushed commits aren't touched, they remain on the remote.
+func (r *Repository) Undo(branch string, oldHead string) *UndoResult {
+	if r.Branch != branch {
+		return &UndoResult{State: UndoStateBranchChanged}
+	}
+
+	head, err := r.backend.RevParse(r.path, "HEAD")
+	if err != nil {
+		return &UndoResult{State: UndoStateError, Error: err}
+	}
+	if head == oldHead {
+		return &UndoResult{State: UndoStateUnchanged}
+	}
+
+	reflog, err := r.backend.Reflog(r.path)
+	if err != nil {
+		return &UndoResult{State: UndoStateError, Error: err}
+	}
+
+	found := false
+	for _, commit := range reflog {
+		if commit == oldHead {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return &UndoResult{State: UndoStateNotInReflog}
+	}
+
+	err = r.backend.ResetKeep(r.path, oldHead)
+	if err != nil {
+		return &UndoResult{State: UndoStateError, Error: err}
+	}
+
+	return &UndoResult{State: UndoStateReset}
+}
@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/benweidig/tortuga/repo"
+)
+
+// WriteCheckoutStatus writes the current status of switching/creating branches to the provided Writer
+func WriteCheckoutStatus(w io.Writer, repos []*repo.Repository, results []*repo.CheckoutResult) {
+	columnizer := newColumnizer().Prioritize(statusColumn)
+	columnizer.AddRow(theme.header("REPOSITORY"), theme.header("BRANCH"), theme.header("STATUS"))
+
+	for idx, r := range repos {
+		name := theme.paint(rolePending, r.Name)
+		branch := theme.paint(rolePending, r.Branch)
+		var status string
+
+		result := results[idx]
+		if result == nil {
+			columnizer.AddRow(name, branch, theme.paint(rolePending, "..."))
+			continue
+		}
+
+		switch result.State {
+		case repo.CheckoutStateSwitched:
+			name = theme.paint(roleAttention, r.Name)
+			branch = theme.paint(roleAttention, r.Branch)
+			status = theme.paint(roleSynced, "switched")
+
+		case repo.CheckoutStateCreated:
+			name = theme.paint(roleAttention, r.Name)
+			branch = theme.paint(roleAttention, r.Branch)
+			status = theme.paint(roleSynced, "created")
+
+		case repo.CheckoutStateCurrent:
+			status = theme.paint(roleClean, "-")
+
+		case repo.CheckoutStateNoSuchBranch:
+			status = theme.paint(roleSkipped, "no such branch")
+
+		case repo.CheckoutStateBranchExists:
+			status = theme.paint(roleSkipped, "branch exists")
+
+		case repo.CheckoutStateLocalChanges:
+			status = theme.paint(roleSkipped, "local changes")
+
+		case repo.CheckoutStateError:
+			name = theme.paint(roleError, r.Name)
+			branch = theme.paint(roleError, r.Branch)
+			status = theme.paint(roleError, result.Error.Error())
+		}
+
+		columnizer.AddRow(name, branch, status)
+	}
+
+	fmt.Fprintln(w, columnizer.FitTo(w))
+}
@@ -1,9 +1,11 @@
 package ui
 
 import (
+	"io"
 	"regexp"
 	"strings"
 	"sync"
+	"unicode/utf8"
 
 	"github.com/mattn/go-runewidth"
 )
@@ -11,15 +13,43 @@ import (
 type columnizer struct {
 	rows []*columnizerRow
 	mtx  *sync.RWMutex
+
+	// maxWidth truncates the widest columns to fit, 0 means unlimited
+	maxWidth int
+
+	// priority is the column truncated last, -1 for none
+	priority int
 }
 
+// statusColumn is the index of the status column of the repository tables
+const statusColumn = 2
+
+// minColumnWidth is the width a column is never truncated below
+const minColumnWidth = 6
+
 // NewColumnizer creates a new table with sensible defaults
 func newColumnizer() *columnizer {
 	return &columnizer{
-		mtx: new(sync.RWMutex),
+		mtx:      new(sync.RWMutex),
+		priority: -1,
 	}
 }
 
+// Prioritize lets the column keep its width as long as possible when truncating,
+// e.g. for a status column
+func (t *columnizer) Prioritize(colIdx int) *columnizer {
+	t.priority = colIdx
+	return t
+}
+
+// FitTo limits the table to the width of the writer, if it's a terminal with a known width
+func (t *columnizer) FitTo(w io.Writer) *columnizer {
+	if sized, ok := w.(interface{ Width() int }); ok {
+		t.maxWidth = sized.Width()
+	}
+	return t
+}
+
 func (t *columnizer) AddRow(contents ...string) {
 	// We don't want to have a half-build table so we need a lock for updating content
 	t.mtx.Lock()
@@ -54,6 +84,10 @@ func (t *columnizer) String() string {
 		}
 	}
 
+	if t.maxWidth > 0 {
+		t.shrink(colWidths)
+	}
+
 	// Remove outer border
 	cols := len(colWidths)
 	borderedCols := cols
@@ -61,6 +95,7 @@ func (t *columnizer) String() string {
 
 	// Holds the string representation of the table
 	var builder strings.Builder
+	separator := theme.separator()
 
 	// Build table data
 	for _, row := range t.rows {
@@ -71,6 +106,9 @@ func (t *columnizer) String() string {
 			// the empty cells with spaces
 			if colIdx < len(row.cells) {
 				cell := row.cells[colIdx]
+				if cell.displayWidth > colWidth {
+					cell = newColumnizerCell(truncate(cell.content, colWidth))
+				}
 				builder.WriteString(cell.paddedContent(colWidth))
 			} else {
 				if colIdx < cols-1 {
@@ -81,7 +119,7 @@ func (t *columnizer) String() string {
 			}
 
 			if colIdx < borderedCols {
-				builder.WriteString(" │ ")
+				builder.WriteString(separator)
 			}
 		}
 		builder.WriteString("\n")
@@ -90,6 +128,80 @@ func (t *columnizer) String() string {
 	return builder.String()
 }
 
+// shrink narrows the widest columns until the table fits the maximum width.
+// The prioritized column is only narrowed if all others are at their minimum.
+func (t *columnizer) shrink(colWidths []int) {
+	total := runewidth.StringWidth(theme.separator()) * (len(colWidths) - 1)
+	for _, colWidth := range colWidths {
+		total += colWidth
+	}
+
+	for ; total > t.maxWidth; total-- {
+		widest := -1
+		for colIdx, colWidth := range colWidths {
+			if colIdx == t.priority || colWidth <= minColumnWidth {
+				continue
+			}
+			if widest < 0 || colWidth > colWidths[widest] {
+				widest = colIdx
+			}
+		}
+
+		if widest < 0 {
+			if t.priority < 0 || t.priority >= len(colWidths) || colWidths[t.priority] <= minColumnWidth {
+				return
+			}
+			widest = t.priority
+		}
+
+		colWidths[widest]--
+	}
+}
+
+// truncate shortens the content to the display width with the ellipsis of the theme.
+// ANSI color codes are kept, and reset after the ellipsis.
+func truncate(content string, width int) string {
+	ellipsis := theme.Ellipsis
+
+	maxWidth := width - runewidth.StringWidth(ellipsis)
+	if maxWidth < 0 {
+		return ""
+	}
+
+	codes := ansiColorCodesRegexp.FindAllStringIndex(content, -1)
+
+	var builder strings.Builder
+	currentWidth := 0
+	colored := false
+
+	for idx := 0; idx < len(content); {
+		if len(codes) > 0 && codes[0][0] == idx {
+			builder.WriteString(content[codes[0][0]:codes[0][1]])
+			idx = codes[0][1]
+			codes = codes[1:]
+			colored = true
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(content[idx:])
+		runeWidth := runewidth.RuneWidth(r)
+		if currentWidth+runeWidth > maxWidth {
+			break
+		}
+
+		builder.WriteRune(r)
+		currentWidth += runeWidth
+		idx += size
+	}
+
+	builder.WriteString(ellipsis)
+	if colored {
+		builder.WriteString("\x1b[0m")
+	}
+
+	return builder.String()
+}
+
 type columnizerRow struct {
 	cells []*columnizerCell
 }
@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattn/go-runewidth"
+)
+
+func TestColumnizerFitsWidth(t *testing.T) {
+	c := newColumnizer().Prioritize(statusColumn)
+	c.AddRow("REPOSITORY", "BRANCH", "STATUS")
+	c.AddRow("a-repository-with-a-long-name", "feature/long-branch-name", "\x1b[31mauth error\x1b[39m")
+	c.maxWidth = 40
+
+	for _, line := range strings.Split(strings.TrimSuffix(c.String(), "\n"), "\n") {
+		width := runewidth.StringWidth(ansiColorCodesRegexp.ReplaceAllString(line, ""))
+		if width > c.maxWidth {
+			t.Errorf("line %q is %d wide, want at most %d", line, width, c.maxWidth)
+		}
+		if strings.Contains(line, "auth") && !strings.Contains(line, "auth error") {
+			t.Errorf("status column was truncated: %q", line)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		content string
+		width   int
+		want    string
+	}{
+		{"repository", 6, "repos…"},
+		{"\x1b[31mrepository\x1b[39m", 6, "\x1b[31mrepos…\x1b[0m"},
+		{"日本語のリポジトリ", 6, "日本…"},
+		{"repository", 0, ""},
+	}
+
+	for _, tt := range tests {
+		if got := truncate(tt.content, tt.width); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.content, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestASCIITheme(t *testing.T) {
+	SetTheme(mustTheme("ascii"))
+	t.Cleanup(func() {
+		SetTheme(mustTheme("default"))
+	})
+
+	c := newColumnizer()
+	c.AddRow("REPOSITORY", "STATUS")
+	c.AddRow("a-repository-with-a-long-name", "up to date")
+	c.maxWidth = 25
+
+	want := "REPOSITORY   | STATUS    \na-reposit... | up to date\n"
+	if got := c.String(); got != want {
+		t.Errorf("table = %q, want %q", got, want)
+	}
+}
+
+func TestPhysicalLines(t *testing.T) {
+	tests := []struct {
+		content string
+		width   int
+		want    int
+	}{
+		{"a\nb\n", 80, 2},
+		{strings.Repeat("x", 80) + "\n", 80, 1},
+		{strings.Repeat("x", 81) + "\n", 80, 2},
+		{"\x1b[31m" + strings.Repeat("x", 80) + "\x1b[39m\n", 80, 1},
+		{strings.Repeat("x", 200) + "\n", 0, 1},
+	}
+
+	for _, tt := range tests {
+		if got := physicalLines([]byte(tt.content), tt.width); got != tt.want {
+			t.Errorf("physicalLines(%q, %d) = %d, want %d", tt.content, tt.width, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/benweidig/tortuga/repo"
+)
+
+// WriteExecStatus writes the current status of a command run in all repositories to the provided Writer
+func WriteExecStatus(w io.Writer, repos []*repo.Repository, results []*repo.ExecResult) {
+	columnizer := newColumnizer().Prioritize(statusColumn)
+	columnizer.AddRow(theme.header("REPOSITORY"), theme.header("BRANCH"), theme.header("STATUS"))
+
+	for idx, r := range repos {
+		name := theme.paint(rolePending, r.Name)
+		branch := theme.paint(rolePending, r.Branch)
+		var status string
+
+		result := results[idx]
+		switch {
+		case result == nil:
+			status = theme.paint(rolePending, "...")
+
+		case result.Running:
+			name = theme.paint(roleAttention, r.Name)
+			branch = theme.paint(roleAttention, r.Branch)
+			status = theme.paint(roleHighlight, "running")
+
+		case result.Error != nil:
+			name = theme.paint(roleError, r.Name)
+			branch = theme.paint(roleError, r.Branch)
+			status = theme.paint(roleError, result.Error.Error())
+
+		case result.ExitCode != 0:
+			name = theme.paint(roleError, r.Name)
+			branch = theme.paint(roleError, r.Branch)
+			status = theme.paintf(roleError, "failed (exit %d)", result.ExitCode)
+
+		default:
+			status = theme.paint(roleSynced, "ok")
+		}
+
+		columnizer.AddRow(name, branch, status)
+	}
+
+	fmt.Fprintln(w, columnizer.FitTo(w))
+}
+
+// WriteExecOutput writes the collected output of a command, grouped by repository, to the provided Writer
+func WriteExecOutput(w io.Writer, repos []*repo.Repository, results []*repo.ExecResult) {
+	for idx, r := range repos {
+		result := results[idx]
+		if result == nil {
+			continue
+		}
+
+		output := bytes.TrimRight(result.Output, "\n")
+		if len(output) == 0 {
+			continue
+		}
+
+		if result.Failed() {
+			fmt.Fprintln(w, theme.paint(roleError, "==> "+r.Name))
+		} else {
+			fmt.Fprintln(w, theme.paint(roleAttention, "==> "+r.Name))
+		}
+		fmt.Fprintf(w, "%s\n\n", output)
+	}
+}
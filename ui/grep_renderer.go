@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/benweidig/tortuga/repo"
+)
+
+// GrepHitsColumn returns the column of the status table with the hit count of a grep per repository.
+// Repositories without a result are still searched.
+func GrepHitsColumn(results map[*repo.Repository]*repo.GrepResult) ExtraColumn {
+	return ExtraColumn{
+		Header: "HITS",
+		Cell: func(r *repo.Repository) string {
+			result := results[r]
+			switch {
+			case result == nil:
+				return theme.paint(rolePending, "...")
+
+			case result.Error != nil:
+				return theme.paint(roleError, result.Error.Error())
+
+			case len(result.Matches) == 0:
+				return theme.paint(roleClean, "-")
+
+			default:
+				return theme.paintf(roleHighlight, "%d", len(result.Matches))
+			}
+		},
+	}
+}
+
+// WriteGrepMatches writes the matching lines of a repository, prefixed with "repo:path:line", to the provided Writer
+func WriteGrepMatches(w io.Writer, r *repo.Repository, result *repo.GrepResult) {
+	separator := theme.paint(roleMuted, ":")
+	for _, match := range result.Matches {
+		fmt.Fprintf(w, "%s%s%s%s%s%s%s\n",
+			theme.paint(roleAttention, r.Name), separator,
+			theme.paint(roleReference, match.Path), separator,
+			theme.paintf(roleSynced, "%d", match.Line), separator,
+			match.Text)
+	}
+}
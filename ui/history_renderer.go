@@ -0,0 +1,112 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/benweidig/tortuga/history"
+	"github.com/benweidig/tortuga/repo"
+)
+
+// shortHash returns the abbreviated commit hash, or a placeholder if unknown
+func shortHash(hash string) string {
+	if len(hash) < 7 {
+		return "-"
+	}
+	return hash[:7]
+}
+
+// shortRange returns the abbreviated commit range, or a placeholder if unknown
+func shortRange(oldHash string, newHash string) string {
+	return shortHash(oldHash) + ".." + shortHash(newHash)
+}
+
+// WriteHistory writes the runs with all their entries to the provided Writer
+func WriteHistory(w io.Writer, runs []history.Run) {
+	columnizer := newColumnizer().Prioritize(7)
+	columnizer.AddRow(theme.header("RUN"), theme.header("REPOSITORY"), theme.header("BRANCH"), theme.header("HEAD"), theme.header("PUSHED"), theme.header("STASH"), theme.header("DURATION"), theme.header("STATUS"))
+
+	for _, run := range runs {
+		runID := theme.paint(roleAttention, run.ID)
+		if run.IncomingOnly {
+			runID += theme.paint(roleMuted, " (incoming)")
+		}
+
+		for _, entry := range run.Entries {
+			head := theme.paint(roleClean, "-")
+			if entry.OldHead != entry.NewHead {
+				head = theme.paint(roleReference, shortRange(entry.OldHead, entry.NewHead))
+			}
+
+			pushed := theme.paint(roleClean, "-")
+			if len(entry.PushedRange) > 0 {
+				oldHash, newHash, _ := strings.Cut(entry.PushedRange, "..")
+				pushed = theme.paint(roleReference, shortRange(oldHash, newHash))
+			}
+
+			stash := theme.paint(roleMuted, shortHash(entry.StashRef))
+
+			var status string
+			if len(entry.Error) > 0 {
+				status = theme.paint(roleError, entry.Error)
+			} else {
+				status = theme.paint(roleSynced, "synced")
+			}
+
+			columnizer.AddRow(runID, theme.paint(roleText, entry.Repository), theme.paint(roleMuted, entry.Branch), head, pushed, stash, theme.paint(roleMuted, entry.Duration.Round(time.Millisecond).String()), status)
+
+			// Only the first entry of a run shows its ID
+			runID = ""
+		}
+	}
+
+	fmt.Fprintln(w, columnizer.FitTo(w))
+}
+
+// WriteUndoStatus writes the current status of undoing a run to the provided Writer
+func WriteUndoStatus(w io.Writer, repos []*repo.Repository, entries []history.Entry, results []*repo.UndoResult) {
+	columnizer := newColumnizer().Prioritize(statusColumn)
+	columnizer.AddRow(theme.header("REPOSITORY"), theme.header("BRANCH"), theme.header("STATUS"))
+
+	for idx, r := range repos {
+		name := theme.paint(rolePending, r.Name)
+		branch := theme.paint(rolePending, entries[idx].Branch)
+		var status string
+
+		result := results[idx]
+		if result == nil {
+			columnizer.AddRow(name, branch, theme.paint(rolePending, "..."))
+			continue
+		}
+
+		switch result.State {
+		case repo.UndoStateReset:
+			name = theme.paint(roleAttention, r.Name)
+			branch = theme.paint(roleAttention, entries[idx].Branch)
+			status = theme.paintf(roleSynced, "reset to %s", shortHash(entries[idx].OldHead))
+			if len(entries[idx].PushedRange) > 0 {
+				status += theme.paint(roleSkipped, " (pushed commits remain on remote)")
+			}
+
+		case repo.UndoStateUnchanged:
+			status = theme.paint(roleClean, "-")
+
+		case repo.UndoStateBranchChanged:
+			status = theme.paintf(roleSkipped, "branch changed to %s", r.Branch)
+
+		case repo.UndoStateNotInReflog:
+			status = theme.paint(roleSkipped, "not in reflog")
+
+		case repo.UndoStateError:
+			name = theme.paint(roleError, r.Name)
+			branch = theme.paint(roleError, entries[idx].Branch)
+			status = theme.paint(roleError, result.Error.Error())
+		}
+
+		columnizer.AddRow(name, branch, status)
+	}
+
+	fmt.Fprintln(w, columnizer.FitTo(w))
+}
@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/benweidig/tortuga/repo"
+)
+
+// WriteLogStatus writes the current status of gathering the commits of all repositories to the provided Writer
+func WriteLogStatus(w io.Writer, repos []*repo.Repository, results []*repo.LogResult) {
+	columnizer := newColumnizer().Prioritize(statusColumn)
+	columnizer.AddRow(theme.header("REPOSITORY"), theme.header("BRANCH"), theme.header("COMMITS"))
+
+	for idx, r := range repos {
+		name := theme.paint(rolePending, r.Name)
+		branch := theme.paint(rolePending, r.Branch)
+		var status string
+
+		result := results[idx]
+		switch {
+		case result == nil:
+			status = theme.paint(rolePending, "...")
+
+		case result.Error != nil:
+			name = theme.paint(roleError, r.Name)
+			branch = theme.paint(roleError, r.Branch)
+			status = theme.paint(roleError, result.Error.Error())
+
+		case len(result.Commits) == 0:
+			status = theme.paint(roleClean, "-")
+
+		default:
+			name = theme.paint(roleAttention, r.Name)
+			branch = theme.paint(roleAttention, r.Branch)
+			status = theme.paintf(roleHighlight, "%d", len(result.Commits))
+		}
+
+		columnizer.AddRow(name, branch, status)
+	}
+
+	fmt.Fprintln(w, columnizer.FitTo(w))
+}
+
+// WriteLog writes the commits as a table to the provided Writer
+func WriteLog(w io.Writer, commits []repo.Commit) {
+	if len(commits) == 0 {
+		return
+	}
+
+	columnizer := newColumnizer()
+	columnizer.AddRow(theme.header("DATE"), theme.header("REPOSITORY"), theme.header("COMMIT"), theme.header("AUTHOR"), theme.header("SUBJECT"))
+
+	for _, c := range commits {
+		columnizer.AddRow(
+			theme.paint(roleMuted, c.Date.Local().Format("2006-01-02 15:04")),
+			theme.paint(roleAttention, c.Repository),
+			theme.paint(roleReference, c.Hash[:7]),
+			theme.paint(roleText, c.Author),
+			c.Subject,
+		)
+	}
+
+	fmt.Fprintln(w, columnizer.FitTo(w))
+}
+
+// WriteLogJSON writes the commits as a JSON array to the provided Writer
+func WriteLogJSON(w io.Writer, commits []repo.Commit) error {
+	// An empty array is nicer to consume than null
+	if commits == nil {
+		commits = []repo.Commit{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(commits)
+}
@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/benweidig/tortuga/cache"
+)
+
+// DefaultPromptFormat returns the format of repositories behind and ahead with the symbols of the theme,
+// e.g. "{behind}↓ {ahead}↑"
+func DefaultPromptFormat() string {
+	return "{behind}" + theme.Incoming + " {ahead}" + theme.Outgoing
+}
+
+// FormatPrompt replaces the placeholders of the format with the values of the cached status:
+//
+//	{repos}     total repositories
+//	{behind}    repositories with incoming commits
+//	{ahead}     repositories with outgoing commits
+//	{incoming}  total incoming commits
+//	{outgoing}  total outgoing commits
+//	{dirty}     repositories with changes
+//	{errors}    repositories with errors
+//	{age}       time since the last update
+func FormatPrompt(format string, s *cache.Status) string {
+	var behind, ahead, incoming, outgoing, dirty, errors int
+
+	for _, r := range s.Repositories {
+		if len(r.Error) > 0 {
+			errors++
+			continue
+		}
+		if r.Incoming > 0 {
+			behind++
+		}
+		if r.Outgoing > 0 {
+			ahead++
+		}
+		if r.Changes > 0 {
+			dirty++
+		}
+		incoming += r.Incoming
+		outgoing += r.Outgoing
+	}
+
+	replacer := strings.NewReplacer(
+		"{repos}", strconv.Itoa(len(s.Repositories)),
+		"{behind}", strconv.Itoa(behind),
+		"{ahead}", strconv.Itoa(ahead),
+		"{incoming}", strconv.Itoa(incoming),
+		"{outgoing}", strconv.Itoa(outgoing),
+		"{dirty}", strconv.Itoa(dirty),
+		"{errors}", strconv.Itoa(errors),
+		"{age}", time.Since(s.Updated).Round(time.Second).String(),
+	)
+
+	return replacer.Replace(format)
+}
@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/benweidig/tortuga/repo"
+
+	"github.com/jwalton/gchalk"
+)
+
+var pruneReasons = map[repo.PruneReason]string{
+	repo.PruneReasonMerged:   "merged",
+	repo.PruneReasonSquashed: "squashed",
+	repo.PruneReasonGone:     "gone",
+}
+
+// WriteRepositoryPrunableBranches writes the prunable branches of all repositories to the provided Writer
+func WriteRepositoryPrunableBranches(w io.Writer, repos []*repo.Repository, results []*repo.PruneResult) {
+	columnizer := newColumnizer().Prioritize(statusColumn)
+	columnizer.AddRow(theme.header("REPOSITORY"), theme.header("BRANCH"), theme.header("PRUNABLE BRANCHES"))
+
+	for idx, r := range repos {
+		name := theme.paint(rolePending, r.Name)
+		branch := theme.paint(rolePending, r.Branch)
+		var status string
+
+		result := results[idx]
+		switch {
+		case result == nil:
+			status = theme.paint(rolePending, "...")
+
+		case result.Error != nil:
+			name = theme.paint(roleError, r.Name)
+			branch = theme.paint(roleError, r.Branch)
+			status = theme.paint(roleError, result.Error.Error())
+
+		case len(result.Branches) == 0:
+			status = theme.paint(roleClean, "-")
+
+		default:
+			name = theme.paint(roleAttention, r.Name)
+			branch = theme.paint(roleAttention, r.Branch)
+
+			branchRole := roleHighlight
+			if result.Deleted {
+				branchRole = roleSynced
+			}
+
+			var statusParts []string
+			for _, b := range result.Branches {
+				statusParts = append(statusParts, theme.paint(branchRole, b.Name)+theme.paint(roleMuted, " ("+pruneReasons[b.Reason]+")"))
+			}
+
+			status = strings.Join(statusParts, " ")
+			if result.Deleted && gchalk.GetLevel() == gchalk.LevelNone {
+				status += " (deleted)"
+			}
+		}
+
+		columnizer.AddRow(name, branch, status)
+	}
+
+	fmt.Fprintln(w, columnizer.FitTo(w))
+}
@@ -0,0 +1,110 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/benweidig/tortuga/repo"
+)
+
+// Columns are the optional columns of WriteRepositoryStatus
+var Columns = []string{"commit-age", "fetch-age", "stashes", "upstream", "host", "size"}
+
+// columnHeaders are the headers of the optional columns
+var columnHeaders = map[string]string{
+	"commit-age": "LAST COMMIT",
+	"fetch-age":  "LAST FETCH",
+	"stashes":    "STASHES",
+	"upstream":   "UPSTREAM",
+	"host":       "HOST",
+	"size":       "SIZE",
+}
+
+// ColumnDetails returns the repository details needed to render the optional columns
+func ColumnDetails(columns []string) repo.Detail {
+	var details repo.Detail
+	for _, column := range columns {
+		switch column {
+		case "fetch-age":
+			details |= repo.DetailLastFetch
+		case "stashes":
+			details |= repo.DetailStashes
+		case "size":
+			details |= repo.DetailSize
+		}
+	}
+	return details
+}
+
+// columnCell returns the cell of an optional column. Details not known yet are left empty.
+func columnCell(r *repo.Repository, column string) string {
+	if r.State == repo.StateNone || r.State == repo.StateError {
+		return ""
+	}
+
+	switch column {
+	case "commit-age":
+		return theme.paint(roleMuted, formatAge(r.LastCommit))
+
+	case "fetch-age":
+		return theme.paint(roleMuted, formatAge(r.LastFetch))
+
+	case "stashes":
+		if r.Stashes == 0 {
+			return theme.paint(roleClean, "-")
+		}
+		return theme.paintf(roleStashes, "%d", r.Stashes)
+
+	case "upstream":
+		// The upstream is only interesting if it's not the same branch of the remote
+		if r.UpstreamBranch == r.Remote+"/"+r.Branch {
+			return ""
+		}
+		return theme.paint(roleUpstream, r.UpstreamBranch)
+
+	case "host":
+		return theme.paint(roleMuted, r.RemoteHost)
+
+	case "size":
+		return theme.paint(roleMuted, formatSize(r.Size))
+	}
+
+	return ""
+}
+
+// formatAge returns the time since t in its largest unit, e.g. "3d", or "-" if unknown
+func formatAge(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+
+	age := time.Since(t)
+	switch {
+	case age < time.Minute:
+		return "now"
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	case age < 365*24*time.Hour:
+		return fmt.Sprintf("%dd", int(age.Hours()/24))
+	default:
+		return fmt.Sprintf("%dy", int(age.Hours()/24/365))
+	}
+}
+
+// formatSize returns the size with a binary unit, e.g. "1.5 MiB"
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,182 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/benweidig/tortuga/repo"
+)
+
+// SortOrders are the supported orders of WriteRepositoryStatus
+var SortOrders = []string{"name", "state", "incoming", "outgoing", "changes", "last-commit"}
+
+// Groupings are the supported groupings of WriteRepositoryStatus
+var Groupings = []string{"state", "host", "tag"}
+
+// repositoryGroup is a titled set of repositories
+type repositoryGroup struct {
+	title string
+	repos []*repo.Repository
+}
+
+// Attention states, ordered by how much attention a repository needs
+const (
+	attentionError = iota
+	attentionHookFailed
+	attentionNeedsSync
+	attentionChanges
+	attentionSynced
+	attentionPending
+	attentionNone
+)
+
+var attentionTitles = map[int]string{
+	attentionError:      "errors",
+	attentionHookFailed: "failed hooks",
+	attentionNeedsSync:  "needing sync",
+	attentionChanges:    "local changes",
+	attentionSynced:     "synced",
+	attentionPending:    "pending",
+	attentionNone:       "up to date",
+}
+
+// attention returns how much attention the repository needs
+func attention(r *repo.Repository) int {
+	switch {
+	case r.State == repo.StateError:
+		return attentionError
+	case r.State == repo.StateHookFailed:
+		return attentionHookFailed
+	case r.State == repo.StateNone:
+		return attentionPending
+	case r.State == repo.StateSynced:
+		return attentionSynced
+	case r.NeedsSync():
+		return attentionNeedsSync
+	case r.Changes > 0 || r.Unversioned > 0:
+		return attentionChanges
+	default:
+		return attentionNone
+	}
+}
+
+// sortRepositories returns a sorted copy of the repositories.
+// Counts and dates are sorted descending, ties keep their original order.
+func sortRepositories(repos []*repo.Repository, order string) []*repo.Repository {
+	sorted := append([]*repo.Repository(nil), repos...)
+
+	var less func(a, b *repo.Repository) bool
+	switch order {
+	case "name":
+		less = func(a, b *repo.Repository) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) }
+	case "state":
+		less = func(a, b *repo.Repository) bool { return attention(a) < attention(b) }
+	case "incoming":
+		less = func(a, b *repo.Repository) bool { return a.Incoming > b.Incoming }
+	case "outgoing":
+		less = func(a, b *repo.Repository) bool { return a.Outgoing > b.Outgoing }
+	case "changes":
+		less = func(a, b *repo.Repository) bool { return a.Changes+a.Unversioned > b.Changes+b.Unversioned }
+	case "last-commit":
+		less = func(a, b *repo.Repository) bool { return a.LastCommit.After(b.LastCommit) }
+	default:
+		return sorted
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return less(sorted[i], sorted[j])
+	})
+	return sorted
+}
+
+// groupRepositories splits the repositories into titled groups, keeping their order within each group.
+// Repositories with multiple tags are part of multiple groups. No grouping returns a single untitled group.
+func groupRepositories(repos []*repo.Repository, grouping string) []repositoryGroup {
+	var keys func(r *repo.Repository) []string
+	var fallback string
+
+	switch grouping {
+	case "state":
+		groups := make([]repositoryGroup, attentionNone+1)
+		for _, r := range repos {
+			idx := attention(r)
+			groups[idx].repos = append(groups[idx].repos, r)
+		}
+		for idx := range groups {
+			groups[idx].title = attentionTitles[idx]
+		}
+		return nonEmptyGroups(groups)
+
+	case "host":
+		fallback = "no remote host"
+		keys = func(r *repo.Repository) []string {
+			if len(r.RemoteHost) == 0 {
+				return nil
+			}
+			return []string{r.RemoteHost}
+		}
+
+	case "tag":
+		fallback = "untagged"
+		keys = func(r *repo.Repository) []string { return r.Tags }
+
+	default:
+		return []repositoryGroup{{repos: repos}}
+	}
+
+	byKey := map[string]*repositoryGroup{}
+	var titles []string
+	var rest []*repo.Repository
+
+	for _, r := range repos {
+		rKeys := keys(r)
+		if len(rKeys) == 0 {
+			rest = append(rest, r)
+			continue
+		}
+
+		for _, key := range rKeys {
+			group, ok := byKey[key]
+			if !ok {
+				group = &repositoryGroup{title: key}
+				byKey[key] = group
+				titles = append(titles, key)
+			}
+			group.repos = append(group.repos, r)
+		}
+	}
+
+	sort.Strings(titles)
+
+	groups := make([]repositoryGroup, 0, len(titles)+1)
+	for _, title := range titles {
+		groups = append(groups, *byKey[title])
+	}
+
+	// Repositories without a key come last
+	groups = append(groups, repositoryGroup{title: fallback, repos: rest})
+
+	return nonEmptyGroups(groups)
+}
+
+// nonEmptyGroups removes all groups without repositories
+func nonEmptyGroups(groups []repositoryGroup) []repositoryGroup {
+	var nonEmpty []repositoryGroup
+	for _, group := range groups {
+		if len(group.repos) > 0 {
+			nonEmpty = append(nonEmpty, group)
+		}
+	}
+	return nonEmpty
+}
+
+// dirtyRepositories returns only the repositories needing attention, and how many were left out
+func dirtyRepositories(repos []*repo.Repository) ([]*repo.Repository, int) {
+	var dirty []*repo.Repository
+	for _, r := range repos {
+		if attention(r) != attentionNone {
+			dirty = append(dirty, r)
+		}
+	}
+	return dirty, len(repos) - len(dirty)
+}
@@ -4,113 +4,353 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/benweidig/tortuga/repo"
 
 	"github.com/jwalton/gchalk"
 )
 
-var (
-	chalkYellow     = gchalk.WithYellow()
-	chalkYellowBold = gchalk.WithYellow().WithBold()
-	chalkGreenBold  = gchalk.WithGreen().WithBold()
-	chalkWhite      = gchalk.WithWhite()
-	chalkGray       = gchalk.WithGray()
-)
+// StatusOptions control what WriteRepositoryStatus renders
+type StatusOptions struct {
+	// IncomingOnly if only incoming commits are synced
+	IncomingOnly bool
 
-// WriteRepositoryStatus writes the current status to the provided Writer
-func WriteRepositoryStatus(w io.Writer, repos []*repo.Repository, incomingOnly bool) {
-	columnizer := newColumnizer()
-	columnizer.AddRow(gchalk.Blue("REPOSITORY"), gchalk.Blue("BRANCH"), gchalk.Blue("STATUS"))
+	// Timings adds a column with the duration of each repository
+	Timings bool
 
-	for _, r := range repos {
-		var name string
-		var branch string
-		var status string
+	// Started is when the rendered work started, for the elapsed time in the summary
+	Started time.Time
 
-		if r.NeedsSync() {
-			name = chalkWhite.Bold(r.Name)
-			branch = chalkWhite.Bold(r.Branch)
-		} else {
-			name = gchalk.Gray(r.Name)
-			branch = gchalk.Gray(r.Branch)
-		}
-		switch r.State {
+	// Sort is one of SortOrders, empty keeps the order of the repositories
+	Sort string
+
+	// GroupBy is one of Groupings, empty for no grouping
+	GroupBy string
+
+	// OnlyDirty collapses all repositories not needing any attention into a single line
+	OnlyDirty bool
+
+	// Columns are optional columns added after the status, see Columns
+	Columns []string
 
-		case repo.StateRemoteFetched:
-			var statusParts []string
+	// Extra are columns of other commands added after the optional columns, e.g. the hits of a grep
+	Extra []ExtraColumn
+}
+
+// ExtraColumn is a column added to the status table by another command
+type ExtraColumn struct {
+	Header string
+	Cell   func(r *repo.Repository) string
+}
+
+// WriteRepositoryStatus writes the current status, followed by a summary, to the provided Writer
+func WriteRepositoryStatus(w io.Writer, repos []*repo.Repository, opts StatusOptions) {
+	columnizer := newColumnizer().Prioritize(statusColumn)
 
-			hasIncOut := false
-			if r.Incoming > 0 {
-				statusParts = append(statusParts, chalkYellowBold.Sprintf("%d↓", r.Incoming))
-				hasIncOut = true
+	header := []string{theme.header("REPOSITORY"), theme.header("BRANCH"), theme.header("STATUS")}
+	for _, column := range opts.Columns {
+		header = append(header, theme.header(columnHeaders[column]))
+	}
+	for _, column := range opts.Extra {
+		header = append(header, theme.header(column.Header))
+	}
+	if opts.Timings {
+		header = append(header, theme.header("DURATION"))
+	}
+	columnizer.AddRow(header...)
+
+	shown := repos
+	collapsed := 0
+	if opts.OnlyDirty {
+		shown, collapsed = dirtyRepositories(repos)
+	}
+
+	for _, group := range groupRepositories(sortRepositories(shown, opts.Sort), opts.GroupBy) {
+		if len(group.title) > 0 {
+			columnizer.AddRow(theme.header(gchalk.Bold(group.title)) + theme.paint(roleMuted, fmt.Sprintf(" (%d)", len(group.repos))))
+		}
+
+		for _, r := range group.repos {
+			cells := repositoryRow(r, opts.IncomingOnly)
+			for _, column := range opts.Columns {
+				cells = append(cells, columnCell(r, column))
 			}
-			if r.Outgoing > 0 {
-				statusParts = append(statusParts, chalkYellowBold.Sprintf("%d↑", r.Outgoing))
-				hasIncOut = true
+			for _, column := range opts.Extra {
+				cells = append(cells, column.Cell(r))
 			}
-
-			var changesChalk *gchalk.Builder
-			if hasIncOut {
-				changesChalk = gchalk.WithWhite()
-			} else {
-				changesChalk = gchalk.WithGray()
+			if opts.Timings {
+				cells = append(cells, durationCell(r))
 			}
+			columnizer.AddRow(cells...)
+		}
+	}
 
-			if r.Changes > 0 {
-				statusParts = append(statusParts, changesChalk.Sprintf("%d*", r.Changes))
-			} else {
-				if r.Noop() {
-					statusParts = append(statusParts, gchalk.Gray("-"))
-				}
-			}
+	fmt.Fprint(w, columnizer.FitTo(w))
+	if collapsed > 0 {
+		fmt.Fprintln(w, theme.paint(roleMuted, fmt.Sprintf("%d %s up to date", collapsed, plural(collapsed, "repository", "repositories"))))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, summaryLine(repo.Summarize(repos, opts.IncomingOnly), time.Since(opts.Started)))
+}
 
-			if r.Unversioned > 0 {
-				if r.Incoming > 0 || r.Outgoing > 0 {
-					statusParts = append(statusParts, chalkWhite.Sprintf("%d?", r.Unversioned))
-				} else {
-					statusParts = append(statusParts, chalkGray.Sprintf("%d?", r.Unversioned))
-				}
+// durationCell returns the time spent on a repository, or nothing if no work was done so far
+func durationCell(r *repo.Repository) string {
+	duration := r.Progress().Duration
+	if duration == 0 {
+		return ""
+	}
+	return theme.paint(roleMuted, formatDuration(duration))
+}
 
-			}
+// formatDuration rounds the duration to a readable precision
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
+
+// summaryLine returns the totals of the summary and the elapsed time as a single line
+func summaryLine(s repo.Summary, elapsed time.Duration) string {
+	parts := []string{
+		theme.paintf(roleAttention, "%d %s", s.Repositories, plural(s.Repositories, "repository", "repositories")),
+		summaryCount(s.UpToDate, "up to date", roleClean),
+		summaryCount(s.NeedsSync, "needing sync", roleAttention),
+		summaryCount(s.Synced, "synced", roleSynced),
+		summaryCount(s.Errors, plural(s.Errors, "error", "errors"), roleError),
+		summaryCount(s.Skipped, "skipped", roleSkipped),
+	}
+
+	// Only shown if any, as hooks are optional
+	if s.HookFailures > 0 {
+		parts = append(parts, summaryCount(s.HookFailures, plural(s.HookFailures, "hook failed", "hooks failed"), roleHookFailed))
+	}
+
+	var commits []string
+	if s.Incoming > 0 {
+		commits = append(commits, theme.paintf(roleNeedsSync, "%d%s", s.Incoming, theme.Incoming))
+	}
+	if s.Outgoing > 0 {
+		commits = append(commits, theme.paintf(roleNeedsSync, "%d%s", s.Outgoing, theme.Outgoing))
+	}
+	if len(commits) == 0 {
+		commits = append(commits, theme.paint(roleClean, theme.Clean))
+	}
+
+	return strings.Join(parts, theme.paint(roleMuted, ", ")) +
+		theme.paint(roleMuted, theme.separator()) + strings.Join(commits, " ") +
+		theme.paint(roleMuted, theme.separator()+formatDuration(elapsed))
+}
+
+// summaryCount highlights a count of the summary with the color of the role, unless it's zero
+func summaryCount(count int, label string, role string) string {
+	if count == 0 {
+		return theme.paintf(roleClean, "%d %s", count, label)
+	}
+	return theme.paintf(role, "%d %s", count, label)
+}
+
+// plural returns the singular or plural form, depending on the count
+func plural(count int, singular string, plural string) string {
+	if count == 1 {
+		return singular
+	}
+	return plural
+}
+
+// repositoryRow returns the name, branch and status cells of a repository
+func repositoryRow(r *repo.Repository, incomingOnly bool) []string {
+	// The progress is taken once, as it changes while rendering
+	currentProgress := r.Progress()
+
+	var name string
+	var branch string
+	var statusParts []string
+
+	if r.NeedsSync() {
+		name = theme.paint(roleAttention, r.Name)
+		branch = theme.paint(roleAttention, r.Branch)
+	} else {
+		name = theme.paint(roleClean, r.Name)
+		branch = theme.paint(roleClean, r.Branch)
+	}
+
+	switch {
+	case r.State == repo.StateError:
+		name = theme.paint(roleError, r.Name)
+		branch = theme.paint(roleError, r.Branch)
+		statusParts = append(statusParts, theme.paint(roleError, r.Error.Error()), label("error"))
+
+	case r.State == repo.StateHookFailed:
+		name = theme.paint(roleHookFailed, r.Name)
+		branch = theme.paint(roleHookFailed, r.Branch)
+		statusParts = append(statusParts, theme.paint(roleHookFailed, r.Error.Error()), label("hook failed"))
 
-			status = strings.Join(statusParts, " ")
+	case currentProgress.Retry > 0:
+		statusParts = append(statusParts, theme.paintf(roleRetry, "retry %d/%d", currentProgress.Retry, r.MaxRetries), label("retrying"))
 
-		case repo.StateSynced:
-			var statusParts []string
+	case r.State == repo.StateRemoteFetched || r.State == repo.StateNoSyncNeeded:
+		if r.Incoming > 0 {
+			statusParts = append(statusParts, theme.paintf(roleNeedsSync, "%d%s", r.Incoming, theme.Incoming))
+		}
+		if r.Outgoing > 0 {
+			statusParts = append(statusParts, theme.paintf(roleNeedsSync, "%d%s", r.Outgoing, theme.Outgoing))
+		}
+
+		// Local changes are only important if they need to be stashed for syncing
+		changesRole := roleClean
+		if r.NeedsSync() {
+			changesRole = roleChanges
+		}
+
+		if r.Changes > 0 {
+			statusParts = append(statusParts, theme.paintf(changesRole, "%d%s", r.Changes, theme.Changes))
+		}
+		if r.Unversioned > 0 {
+			statusParts = append(statusParts, theme.paintf(changesRole, "%d%s", r.Unversioned, theme.Unversioned))
+		}
+
+		switch {
+		case r.NeedsSync():
+			statusParts = append(statusParts, label("needs sync"))
+		case r.Noop():
+			statusParts = append(statusParts, theme.paint(roleClean, theme.Clean), label("up to date"))
+		default:
+			statusParts = append(statusParts, label("local changes"))
+		}
 
-			hasSynced := false
+	case r.State == repo.StateSynced:
+		hasSynced := false
 
-			if r.Incoming > 0 {
-				statusParts = append(statusParts, chalkGreenBold.Sprintf("%d↓", r.Incoming))
+		if r.Incoming > 0 {
+			statusParts = append(statusParts, theme.paintf(roleSynced, "%d%s", r.Incoming, theme.Incoming))
+			hasSynced = true
+		}
+		if r.Outgoing > 0 {
+			if incomingOnly {
+				statusParts = append(statusParts, theme.paintf(roleSkipped, "%d%s", r.Outgoing, theme.Outgoing))
+			} else {
+				statusParts = append(statusParts, theme.paintf(roleSynced, "%d%s", r.Outgoing, theme.Outgoing))
 				hasSynced = true
 			}
-			if r.Outgoing > 0 {
-				if incomingOnly {
-					statusParts = append(statusParts, chalkYellow.Sprintf("%d↑", r.Outgoing))
-				} else {
-					statusParts = append(statusParts, chalkGreenBold.Sprintf("%d↑", r.Outgoing))
-					hasSynced = true
-				}
-			}
+		}
 
-			if hasSynced && gchalk.GetLevel() == gchalk.LevelNone {
-				statusParts = append(statusParts, "(synced)")
-			}
+		if hasSynced {
+			statusParts = append(statusParts, label("synced"))
+		} else {
+			statusParts = append(statusParts, label("skipped"))
+		}
 
-			status = strings.Join(statusParts, " ")
+	case r.State == repo.StateNeedsSync:
+		statusParts = append(statusParts, theme.paint(rolePending, formatProgress(currentProgress)), label("syncing"))
 
-		case repo.StateError:
-			name = gchalk.Red(r.Name)
-			branch = gchalk.Red(r.Branch)
-			status = gchalk.Red(r.Error.Error())
+	default:
+		statusParts = append(statusParts, theme.paint(rolePending, formatProgress(currentProgress)), label("pending"))
+	}
 
-		default:
-			status = gchalk.Gray("...")
+	return []string{name, branch, joinParts(statusParts)}
+}
+
+// SpinnerInterval is the duration of a single frame of the spinner
+const SpinnerInterval = 100 * time.Millisecond
+
+// formatProgress returns the animated phase of a repository being worked on with its elapsed seconds,
+// e.g. "⠙ fetching 3s", or "..." if the work hasn't started yet.
+// Fetches include the progress reported by git, e.g. "⠙ fetching 45% 2.40 MiB/s 3s" or "⠙ resolving 80% 5s".
+func formatProgress(p repo.Progress) string {
+	if p.Phase == repo.PhaseNone {
+		return "..."
+	}
+
+	elapsed := time.Since(p.PhaseStarted)
+	frame := theme.Spinner[int(elapsed/SpinnerInterval)%len(theme.Spinner)]
+
+	phase := p.Phase.String()
+	var fetchParts []string
+
+	if p.Phase == repo.PhaseFetching && len(p.Fetch.Stage) > 0 {
+		if p.Fetch.Stage == "Resolving deltas" {
+			phase = "resolving"
 		}
+		fetchParts = append(fetchParts, fmt.Sprintf("%d%%", p.Fetch.Percent), p.Fetch.Rate)
+	}
+
+	parts := append([]string{frame, phase}, fetchParts...)
+
+	parts = append(parts, fmt.Sprintf("%ds", int(elapsed.Seconds())))
+
+	return joinParts(parts)
+}
+
+// joinParts joins the non-empty parts with spaces
+func joinParts(parts []string) string {
+	var nonEmpty []string
+	for _, part := range parts {
+		if len(part) > 0 {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, " ")
+}
 
-		columnizer.AddRow(name, branch, status)
+// RepositoryEvent returns what happened to a repository as a single uncolored line,
+// e.g. "repo-a: fetched 2↓" or "repo-b: error auth error"
+func RepositoryEvent(r *repo.Repository, incomingOnly bool) string {
+	if retry := r.Progress().Retry; retry > 0 {
+		return fmt.Sprintf("%s: retry %d/%d", r.Name, retry, r.MaxRetries)
 	}
 
-	fmt.Fprintln(w, columnizer)
+	var event string
+
+	switch r.State {
+	case repo.StateError:
+		event = "error " + r.Error.Error()
+
+	case repo.StateHookFailed:
+		event = r.Error.Error()
+
+	case repo.StateSynced:
+		event = "synced"
+		if r.Incoming > 0 {
+			event += fmt.Sprintf(" %d%s", r.Incoming, theme.Incoming)
+		}
+		if r.Outgoing > 0 {
+			if incomingOnly {
+				event += fmt.Sprintf(", skipped %d%s", r.Outgoing, theme.Outgoing)
+			} else {
+				event += fmt.Sprintf(" %d%s", r.Outgoing, theme.Outgoing)
+			}
+		}
+
+	case repo.StateNone:
+		event = "pending"
+
+	default:
+		counts := plainCounts(r)
+		if len(counts) == 0 {
+			counts = "up to date"
+		}
+		event = "fetched " + counts
+	}
+
+	return r.Name + ": " + event
+}
+
+// plainCounts returns the uncolored incoming, outgoing, changed and unversioned counts, e.g. "2↓ 1*"
+func plainCounts(r *repo.Repository) string {
+	var parts []string
+	if r.Incoming > 0 {
+		parts = append(parts, fmt.Sprintf("%d%s", r.Incoming, theme.Incoming))
+	}
+	if r.Outgoing > 0 {
+		parts = append(parts, fmt.Sprintf("%d%s", r.Outgoing, theme.Outgoing))
+	}
+	if r.Changes > 0 {
+		parts = append(parts, fmt.Sprintf("%d%s", r.Changes, theme.Changes))
+	}
+	if r.Unversioned > 0 {
+		parts = append(parts, fmt.Sprintf("%d%s", r.Unversioned, theme.Unversioned))
+	}
+	return strings.Join(parts, " ")
 }
@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benweidig/tortuga/git"
+	"github.com/benweidig/tortuga/repo"
+
+	"github.com/jwalton/gchalk"
+)
+
+func TestProgress(t *testing.T) {
+	previous := CurrentTheme()
+	SetTheme(mustTheme("ascii"))
+	defer SetTheme(previous)
+
+	if got := formatProgress((&repo.Repository{}).Progress()); got != "..." {
+		t.Errorf("progress without phase = %q, want ...", got)
+	}
+
+	started := time.Now().Add(-2*time.Second - SpinnerInterval*3/2)
+	if got := formatProgress(repo.Progress{Phase: repo.PhaseFetching, PhaseStarted: started}); got != "/ fetching 2s" {
+		t.Errorf("progress = %q, want / fetching 2s", got)
+	}
+
+	fetchProgress := git.FetchProgress{Stage: "Receiving objects", Percent: 45, Rate: "2.40 MiB/s"}
+	if got := formatProgress(repo.Progress{Phase: repo.PhaseFetching, PhaseStarted: started, Fetch: fetchProgress}); got != "/ fetching 45% 2.40 MiB/s 2s" {
+		t.Errorf("progress = %q, want / fetching 45%% 2.40 MiB/s 2s", got)
+	}
+
+	fetchProgress = git.FetchProgress{Stage: "Resolving deltas", Percent: 80}
+	if got := formatProgress(repo.Progress{Phase: repo.PhaseFetching, PhaseStarted: started, Fetch: fetchProgress}); got != "/ resolving 80% 2s" {
+		t.Errorf("progress = %q, want / resolving 80%% 2s", got)
+	}
+}
+
+func TestGrepHitsColumn(t *testing.T) {
+	level := gchalk.GetLevel()
+	gchalk.SetLevel(gchalk.LevelNone)
+	defer gchalk.SetLevel(level)
+
+	found := &repo.Repository{Name: "found", Branch: "main", State: repo.StateRemoteFetched}
+	missing := &repo.Repository{Name: "missing", Branch: "main", State: repo.StateRemoteFetched}
+	searching := &repo.Repository{Name: "searching", Branch: "main"}
+
+	hits := map[*repo.Repository]*repo.GrepResult{
+		found:   {Matches: make([]git.GrepMatch, 3)},
+		missing: {},
+	}
+
+	var b strings.Builder
+	WriteRepositoryStatus(&b, []*repo.Repository{found, missing, searching}, StatusOptions{Extra: []ExtraColumn{GrepHitsColumn(hits)}})
+
+	lines := strings.Split(b.String(), "\n")
+	for idx, want := range []string{"HITS", "3", "-", "..."} {
+		if cells := strings.Split(lines[idx], " │ "); strings.TrimSpace(cells[len(cells)-1]) != want {
+			t.Errorf("line %q, want last column %q", lines[idx], want)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/benweidig/tortuga/git"
+	"github.com/benweidig/tortuga/repo"
+)
+
+// ReportFormats are the supported formats of WriteReport
+var ReportFormats = []string{"markdown", "html"}
+
+// attentionLabels describe the state of a single repository
+var attentionLabels = map[int]string{
+	attentionError:      "error",
+	attentionHookFailed: "hook failed",
+	attentionNeedsSync:  "needs sync",
+	attentionChanges:    "local changes",
+	attentionSynced:     "synced",
+	attentionPending:    "pending",
+	attentionNone:       "up to date",
+}
+
+type report struct {
+	Generated time.Time
+	Headers   []string
+	Groups    []reportGroup
+	Collapsed string
+	Summary   string
+	Errors    []reportError
+}
+
+type reportGroup struct {
+	Title string
+	Rows  []reportRow
+}
+
+type reportRow struct {
+	Name   string
+	Branch string
+	State  string
+	Class  string
+	Status string
+	Cells  []string
+}
+
+type reportError struct {
+	Name    string
+	Message string
+	Details string
+}
+
+// WriteReport writes the status of the repositories as a standalone document in the format,
+// see ReportFormats. It contains the same data as WriteRepositoryStatus, the details of all errors,
+// and when it was generated.
+func WriteReport(w io.Writer, format string, repos []*repo.Repository, opts StatusOptions, generated time.Time) error {
+	rep := newReport(repos, opts, generated)
+
+	switch format {
+	case "markdown":
+		return writeMarkdownReport(w, rep)
+	case "html":
+		return htmlReportTemplate.Execute(w, rep)
+	default:
+		return fmt.Errorf("unknown report format '%s'", format)
+	}
+}
+
+// newReport gathers the uncolored content of the report
+func newReport(repos []*repo.Repository, opts StatusOptions, generated time.Time) report {
+	rep := report{
+		Generated: generated,
+		Headers:   []string{"REPOSITORY", "BRANCH", "STATE", "STATUS"},
+		Summary:   stripColors(summaryLine(repo.Summarize(repos, opts.IncomingOnly), time.Since(opts.Started))),
+	}
+
+	for _, column := range opts.Columns {
+		rep.Headers = append(rep.Headers, columnHeaders[column])
+	}
+	if opts.Timings {
+		rep.Headers = append(rep.Headers, "DURATION")
+	}
+
+	shown := repos
+	collapsed := 0
+	if opts.OnlyDirty {
+		shown, collapsed = dirtyRepositories(repos)
+	}
+	if collapsed > 0 {
+		rep.Collapsed = fmt.Sprintf("%d %s up to date", collapsed, plural(collapsed, "repository", "repositories"))
+	}
+
+	for _, group := range groupRepositories(sortRepositories(shown, opts.Sort), opts.GroupBy) {
+		reportGroup := reportGroup{Title: group.title}
+		for _, r := range group.repos {
+			reportGroup.Rows = append(reportGroup.Rows, newReportRow(r, opts))
+		}
+		rep.Groups = append(rep.Groups, reportGroup)
+	}
+
+	for _, r := range repos {
+		if r.State != repo.StateError && r.State != repo.StateHookFailed {
+			continue
+		}
+
+		reportErr := reportError{
+			Name:    r.Name,
+			Message: r.Error.Error(),
+		}
+
+		var ge *git.ExternalError
+		var he *repo.HookError
+		switch {
+		case errors.As(r.Error, &ge):
+			reportErr.Details = strings.TrimSpace(ge.StdErr)
+		case errors.As(r.Error, &he):
+			reportErr.Details = strings.TrimSpace(string(he.Output))
+		}
+
+		rep.Errors = append(rep.Errors, reportErr)
+	}
+
+	return rep
+}
+
+// newReportRow returns the uncolored cells of a repository, and its state
+func newReportRow(r *repo.Repository, opts StatusOptions) reportRow {
+	state := attentionLabels[attention(r)]
+
+	row := reportRow{
+		Name:   r.Name,
+		Branch: r.Branch,
+		State:  state,
+		Class:  "state-" + strings.ReplaceAll(state, " ", "-"),
+	}
+
+	switch r.State {
+	case repo.StateError, repo.StateHookFailed:
+		row.Status = r.Error.Error()
+	case repo.StateNone, repo.StateNeedsSync:
+		row.Status = "..."
+	default:
+		row.Status = plainCounts(r)
+		if len(row.Status) == 0 {
+			row.Status = theme.Clean
+		}
+	}
+
+	for _, column := range opts.Columns {
+		row.Cells = append(row.Cells, stripColors(columnCell(r, column)))
+	}
+	if opts.Timings {
+		row.Cells = append(row.Cells, stripColors(durationCell(r)))
+	}
+
+	return row
+}
+
+// stripColors removes all ANSI color codes
+func stripColors(s string) string {
+	return ansiColorCodesRegexp.ReplaceAllString(s, "")
+}
+
+// writeMarkdownReport writes the report as GitHub flavored markdown
+func writeMarkdownReport(w io.Writer, rep report) error {
+	var b strings.Builder
+
+	fmt.Fprintln(&b, "# Tortuga report")
+	fmt.Fprintln(&b)
+	fmt.Fprintf(&b, "Generated %s\n", rep.Generated.Format(time.RFC1123))
+
+	for _, group := range rep.Groups {
+		fmt.Fprintln(&b)
+		if len(group.Title) > 0 {
+			fmt.Fprintf(&b, "## %s (%d)\n\n", markdownEscape(group.Title), len(group.Rows))
+		}
+
+		fmt.Fprintf(&b, "| %s |\n", strings.Join(rep.Headers, " | "))
+		fmt.Fprintf(&b, "|%s\n", strings.Repeat(" --- |", len(rep.Headers)))
+
+		for _, row := range group.Rows {
+			cells := append([]string{row.Name, row.Branch, row.State, row.Status}, row.Cells...)
+			for idx := range cells {
+				cells[idx] = markdownEscape(cells[idx])
+			}
+			fmt.Fprintf(&b, "| %s |\n", strings.Join(cells, " | "))
+		}
+	}
+
+	if len(rep.Collapsed) > 0 {
+		fmt.Fprintf(&b, "\n%s\n", rep.Collapsed)
+	}
+
+	fmt.Fprintf(&b, "\n**%s**\n", markdownEscape(rep.Summary))
+
+	if len(rep.Errors) > 0 {
+		fmt.Fprintln(&b)
+		fmt.Fprintln(&b, "## Errors")
+
+		for _, reportErr := range rep.Errors {
+			fmt.Fprintf(&b, "\n### %s\n\n%s\n", markdownEscape(reportErr.Name), markdownEscape(reportErr.Message))
+			if len(reportErr.Details) > 0 {
+				fmt.Fprintf(&b, "\n```\n%s\n```\n", reportErr.Details)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownEscaper escapes characters with a meaning in markdown inline text and tables
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`", "<", "&lt;", ">", "&gt;")
+
+func markdownEscape(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Tortuga report</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { padding: 0.25em 0.75em; text-align: left; border-bottom: 1px solid #ddd; white-space: nowrap; }
+th { color: #268bd2; }
+pre { background: #f5f5f5; padding: 0.5em; overflow-x: auto; }
+.generated, .collapsed, .state-up-to-date, .state-pending { color: #888; }
+.state-needs-sync { font-weight: bold; }
+.state-needs-sync .status { color: #b58900; }
+.state-local-changes .status { color: #555; }
+.state-synced .status { color: #2e8b57; font-weight: bold; }
+.state-error, .error h3 { color: #c0392b; }
+.state-hook-failed { color: #8e44ad; }
+</style>
+</head>
+<body>
+<h1>Tortuga report</h1>
+<p class="generated">Generated <time datetime="{{.Generated.Format "2006-01-02T15:04:05Z07:00"}}">{{.Generated.Format "Mon, 02 Jan 2006 15:04:05 MST"}}</time></p>
+{{range .Groups}}
+{{if .Title}}<h2>{{.Title}} ({{len .Rows}})</h2>{{end}}
+<table>
+<thead>
+<tr>{{range $.Headers}}<th>{{.}}</th>{{end}}</tr>
+</thead>
+<tbody>
+{{range .Rows}}<tr class="{{.Class}}"><td>{{.Name}}</td><td>{{.Branch}}</td><td class="state">{{.State}}</td><td class="status">{{.Status}}</td>{{range .Cells}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</tbody>
+</table>
+{{end}}
+{{if .Collapsed}}<p class="collapsed">{{.Collapsed}}</p>{{end}}
+<p class="summary"><strong>{{.Summary}}</strong></p>
+{{if .Errors}}
+<h2>Errors</h2>
+{{range .Errors}}<div class="error">
+<h3>{{.Name}}</h3>
+<p>{{.Message}}</p>
+{{if .Details}}<pre>{{.Details}}</pre>{{end}}
+</div>
+{{end}}
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benweidig/tortuga/git"
+	"github.com/benweidig/tortuga/repo"
+)
+
+func reportRepositories() []*repo.Repository {
+	return []*repo.Repository{
+		{Name: "a|b", Branch: "main", State: repo.StateRemoteFetched, Incoming: 2},
+		{Name: "<c>", Branch: "main", State: repo.StateError, Error: git.NewExternalError(errors.New("exit status 128"), "fatal: no upstream\n")},
+	}
+}
+
+func TestMarkdownReport(t *testing.T) {
+	var b strings.Builder
+	err := WriteReport(&b, "markdown", reportRepositories(), StatusOptions{Started: time.Now()}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`| a\|b | main | needs sync | 2↓ |`,
+		"### &lt;c&gt;",
+		"```\nfatal: no upstream\n```",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("report is missing %q:\n%s", want, b.String())
+		}
+	}
+	if strings.Contains(b.String(), "\x1b[") {
+		t.Errorf("report contains ANSI codes:\n%s", b.String())
+	}
+}
+
+func TestHTMLReport(t *testing.T) {
+	var b strings.Builder
+	err := WriteReport(&b, "html", reportRepositories(), StatusOptions{Started: time.Now()}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`<tr class="state-needs-sync"><td>a|b</td>`,
+		`<tr class="state-error"><td>&lt;c&gt;</td>`,
+		"<pre>fatal: no upstream</pre>",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("report is missing %q:\n%s", want, b.String())
+		}
+	}
+
+	if err := WriteReport(&b, "pdf", nil, StatusOptions{}, time.Now()); err == nil {
+		t.Error("unknown format didn't fail")
+	}
+}
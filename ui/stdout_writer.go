@@ -2,28 +2,111 @@ package ui
 
 import (
 	"bytes"
+	"fmt"
 	"os"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	isatty "github.com/mattn/go-isatty"
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/term"
 )
 
 // ESCAPE is the ASCII code for escape character
 const ESCAPE = 27
 
-// StdoutWriter is an "in-place" writer for the StdOut
+// forcePlain disables the "in-place" rendering even on terminals
+var forcePlain bool
+
+// SetPlain forces all new StdoutWriters into plain mode
+func SetPlain(plain bool) {
+	forcePlain = plain
+}
+
+// StdoutWriter is an "in-place" writer for the StdOut.
+//
+// If StdOut isn't a terminal, or plain mode is forced, nothing is redrawn. Instead,
+// only events are written line by line, and the last render is written by Done.
 type StdoutWriter struct {
 	buffer             bytes.Buffer
 	writeMtx           *sync.Mutex
 	renderMtx          *sync.Mutex
 	lineBreaks         int
 	preserveLineBreaks int
+
+	plain  bool
+	frame  []byte
+	events bool
 }
 
+// terminalWidth is the width of the terminal, 0 if unknown.
+// It's shared by all StdoutWriters, so the resizing is only watched once per process.
+var (
+	terminalWidth   atomic.Int32
+	watchResizeOnce sync.Once
+)
+
 // NewStdoutWriter returns a new Writer
 func NewStdoutWriter() *StdoutWriter {
-	return &StdoutWriter{
+	w := &StdoutWriter{
 		writeMtx:  &sync.Mutex{},
 		renderMtx: &sync.Mutex{},
+		plain:     forcePlain || !isTerminal(os.Stdout.Fd()),
+	}
+
+	// Without redrawing, nothing needs to fit the terminal
+	if !w.plain {
+		measure()
+		watchResizeOnce.Do(watchResize)
+	}
+
+	return w
+}
+
+// measure updates the width of the terminal
+func measure() {
+	width, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		width = 0
+	}
+	terminalWidth.Store(int32(width))
+}
+
+// Width returns the width of the terminal, 0 if unknown or in plain mode
+func (w *StdoutWriter) Width() int {
+	if w.plain {
+		return 0
 	}
+	return int(terminalWidth.Load())
+}
+
+// physicalLines counts the lines of the content as shown by a terminal of the width,
+// so lines wrapped by the terminal are included
+func physicalLines(content []byte, width int) int {
+	lines := bytes.Count(content, []byte("\n"))
+	if width <= 0 {
+		return lines
+	}
+
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		lineWidth := runewidth.StringWidth(ansiColorCodesRegexp.ReplaceAllString(string(line), ""))
+		if lineWidth > width {
+			lines += (lineWidth - 1) / width
+		}
+	}
+
+	return lines
+}
+
+// isTerminal checks if the file descriptor is a terminal, including Cygwin/MSYS2 terminals on Windows
+func isTerminal(fd uintptr) bool {
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// Plain returns if the writer is in plain mode
+func (w *StdoutWriter) Plain() bool {
+	return w.plain
 }
 
 // Write adds to its buffers.
@@ -49,8 +132,7 @@ func (w *StdoutWriter) Mark() {
 
 	// Calculate the lines of the current buffer and
 	// mark the last line
-	lines := bytes.Count(bufferBytes, []byte("\n"))
-	w.preserveLineBreaks = lines
+	w.preserveLineBreaks = physicalLines(bufferBytes, w.Width())
 }
 
 // Reset the StdoutWriter to 0
@@ -58,7 +140,9 @@ func (w *StdoutWriter) Reset() {
 	w.writeMtx.Lock()
 	defer w.writeMtx.Unlock()
 
-	w.reset(w.lineBreaks)
+	if !w.plain {
+		w.reset(w.lineBreaks)
+	}
 	w.buffer.Reset()
 
 	w.lineBreaks = 0
@@ -71,7 +155,9 @@ func (w *StdoutWriter) ResetToMarker() {
 	defer w.writeMtx.Unlock()
 
 	diff := w.lineBreaks - w.preserveLineBreaks
-	w.reset(diff)
+	if !w.plain {
+		w.reset(diff)
+	}
 	w.buffer.Reset()
 
 	w.lineBreaks = w.preserveLineBreaks
@@ -94,21 +180,105 @@ func (w *StdoutWriter) Flush() error {
 		return nil
 	}
 
-	breaks := bytes.Count(bufferBytes, []byte("\n"))
-
-	w.lineBreaks += breaks
+	w.lineBreaks += physicalLines(bufferBytes, w.Width())
 
 	_, err := os.Stdout.Write(bufferBytes)
 	w.buffer.Reset()
 	return err
 }
 
-// Render is a mutex locked helper to reset, write, and flush
+// Render is a mutex locked helper to reset, write, and flush.
+// In plain mode, the written content is only kept for Done.
 func (w *StdoutWriter) Render(fn func()) {
 	w.renderMtx.Lock()
 	defer w.renderMtx.Unlock()
 
+	if w.plain {
+		w.writeMtx.Lock()
+		w.buffer.Reset()
+		w.writeMtx.Unlock()
+
+		fn()
+
+		w.writeMtx.Lock()
+		w.frame = append(w.frame[:0], w.buffer.Bytes()...)
+		w.buffer.Reset()
+		w.writeMtx.Unlock()
+		return
+	}
+
 	w.Reset()
 	fn()
 	w.Flush()
 }
+
+// Animate renders fn in the interval in addition to the explicit Render calls,
+// e.g. to animate spinners, until the returned stop is called.
+// In plain mode, nothing is redrawn, so nothing is animated.
+func (w *StdoutWriter) Animate(interval time.Duration, fn func()) (stop func()) {
+	if w.plain {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				w.Render(fn)
+			}
+		}
+	}()
+
+	// No render must happen after stopping, so wait for the ticker to finish
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// Event writes a single line in plain mode, e.g. "repo-a: fetched 2↓".
+// Otherwise, the rendered content already shows it, so it's ignored.
+func (w *StdoutWriter) Event(line string) {
+	if !w.plain {
+		return
+	}
+
+	w.writeMtx.Lock()
+	defer w.writeMtx.Unlock()
+
+	fmt.Fprintln(os.Stdout, line)
+	w.events = true
+}
+
+// Done writes the last rendered content in plain mode, so it's shown once.
+// Otherwise, it's already shown, so there's nothing to do.
+func (w *StdoutWriter) Done() {
+	w.renderMtx.Lock()
+	defer w.renderMtx.Unlock()
+
+	if !w.plain || len(w.frame) == 0 {
+		return
+	}
+
+	w.writeMtx.Lock()
+	defer w.writeMtx.Unlock()
+
+	// The content is separated from the events
+	if w.events {
+		fmt.Fprintln(os.Stdout)
+		w.events = false
+	}
+
+	os.Stdout.Write(w.frame)
+	w.frame = nil
+}
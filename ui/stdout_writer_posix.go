@@ -6,16 +6,27 @@ package ui
 import (
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 )
 
+// enableEscapeSequences has nothing to enable, terminals handle them
+func enableEscapeSequences() bool {
+	return true
+}
+
 func (w *StdoutWriter) reset(lineBreaks int) {
-	if lineBreaks == 0 {
-		fmt.Fprintf(os.Stdout, "%c[2K\r", ESCAPE)
-		return
-	}
+	fmt.Fprint(os.Stdout, resetSequence(lineBreaks))
+}
+
+// watchResize measures the terminal again whenever it's resized, for the rest of the process
+func watchResize() {
+	resized := make(chan os.Signal, 1)
+	signal.Notify(resized, syscall.SIGWINCH)
 
-	for i := 0; i < lineBreaks; i++ {
-		fmt.Fprintf(os.Stdout, "%c[%dA", ESCAPE, 1) // move the cursor up
-		fmt.Fprintf(os.Stdout, "%c[2K\r", ESCAPE)   // clear the line
-	}
+	go func() {
+		for range resized {
+			measure()
+		}
+	}()
 }
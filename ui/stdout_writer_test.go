@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benweidig/tortuga/git"
+	"github.com/benweidig/tortuga/git/gittest"
+	"github.com/benweidig/tortuga/repo"
+)
+
+// TestAnimateDuringUpdate renders the progress while the repository is updated, run it with -race
+func TestAnimateDuringUpdate(t *testing.T) {
+	f := gittest.NewFixture(t)
+
+	backoff := repo.RetryBackoff
+	repo.RetryBackoff = 10 * time.Millisecond
+	t.Cleanup(func() {
+		repo.RetryBackoff = backoff
+	})
+
+	// The redraws go to StdOut
+	stdout := os.Stdout
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = devNull
+	t.Cleanup(func() {
+		os.Stdout = stdout
+		devNull.Close()
+	})
+
+	tests := []struct {
+		name    string
+		backend git.Backend
+	}{
+		{"fetching", git.NewExecBackend()},
+		{"retrying", gittest.NewFakeBackend().Fail(gittest.OpFetch, gittest.TimeoutError())},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoPath := f.Incoming("animated-"+tt.name, 1)
+			f.PushRemote("animated-"+tt.name, "incoming", "more\n")
+
+			r, err := repo.NewRepositoryWithBackend(repoPath, tt.backend)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.MaxRetries = 1
+
+			w := &StdoutWriter{writeMtx: &sync.Mutex{}, renderMtx: &sync.Mutex{}}
+			stop := w.Animate(time.Millisecond, func() {
+				p := r.Progress()
+				fmt.Fprintln(w, formatProgress(p), p.Retry, durationCell(r))
+			})
+
+			err = r.Update()
+			stop()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Incoming != 2 {
+				t.Errorf("incoming = %d, want 2", r.Incoming)
+			}
+		})
+	}
+}
@@ -5,31 +5,28 @@ package ui
 
 import (
 	"fmt"
-	"io"
 	"os"
 	"syscall"
+	"time"
 	"unsafe"
-
-	isatty "github.com/mattn/go-isatty"
 )
 
 var kernel32 = syscall.NewLazyDLL("kernel32.dll")
 
 var (
+	procGetConsoleMode             = kernel32.NewProc("GetConsoleMode")
+	procSetConsoleMode             = kernel32.NewProc("SetConsoleMode")
 	procGetConsoleScreenBufferInfo = kernel32.NewProc("GetConsoleScreenBufferInfo")
 	procSetConsoleCursorPosition   = kernel32.NewProc("SetConsoleCursorPosition")
 	procFillConsoleOutputCharacter = kernel32.NewProc("FillConsoleOutputCharacterW")
 )
 
-type short int16
+// enableVirtualTerminalProcessing is the console mode to handle escape sequences, since Windows 10
+const enableVirtualTerminalProcessing = 0x0004
+
 type dword uint32
 type word uint16
 
-type coord struct {
-	x short
-	y short
-}
-
 type smallRect struct {
 	left   short
 	top    short
@@ -45,43 +42,49 @@ type consoleScreenBufferInfo struct {
 	maximumWindowSize coord
 }
 
-// FdWriter is a writer with a file descriptor.
-type fdWriter interface {
-	io.Writer
-	Fd() uintptr
+// enableEscapeSequences enables the virtual terminal processing of the console.
+// Old hosts don't support it, so the legacy console API is needed instead.
+func enableEscapeSequences() bool {
+	fd := os.Stdout.Fd()
+
+	var mode dword
+	ok, _, _ := procGetConsoleMode.Call(fd, uintptr(unsafe.Pointer(&mode)))
+	if ok == 0 {
+		// Not a console, like a pipe or a Cygwin/MSYS2 terminal, which handle escape sequences themselves
+		return true
+	}
+
+	if mode&enableVirtualTerminalProcessing != 0 {
+		return true
+	}
+
+	ok, _, _ = procSetConsoleMode.Call(fd, uintptr(mode|enableVirtualTerminalProcessing))
+	return ok != 0
 }
 
 func (w *StdoutWriter) reset(lineBreaks int) {
-	if lineBreaks == 0 {
-		fmt.Fprintf(os.Stdout, "%c[2K\r", ESCAPE) // clear the line
+	if EnableEscapeSequences() {
+		fmt.Fprint(os.Stdout, resetSequence(lineBreaks))
 		return
 	}
 
-	var writer io.Writer = os.Stdout
-	f, ok := writer.(fdWriter)
-	if !ok || !isatty.IsTerminal(f.Fd()) {
-		for i := 0; i < w.lineBreaks; i++ {
-			fmt.Fprintf(os.Stdout, "%c[2K\r", ESCAPE)   // clear the line
-			fmt.Fprintf(os.Stdout, "%c[%dA", ESCAPE, 0) // move the cursor up
-		}
-		return
-	}
+	fd := os.Stdout.Fd()
 
-	fd := f.Fd()
 	var csbi consoleScreenBufferInfo
 	procGetConsoleScreenBufferInfo.Call(fd, uintptr(unsafe.Pointer(&csbi)))
 
-	for i := 0; i < lineBreaks; i++ {
-		// move the cursor up
-		csbi.cursorPosition.y--
-		procSetConsoleCursorPosition.Call(fd, uintptr(*(*int32)(unsafe.Pointer(&csbi.cursorPosition))))
-		// clear the line
-		cursor := coord{
-			x: csbi.window.left,
-			y: csbi.window.top + csbi.cursorPosition.y,
-		}
-		var count, w dword
-		count = dword(csbi.size.x)
-		procFillConsoleOutputCharacter.Call(fd, uintptr(' '), uintptr(count), *(*uintptr)(unsafe.Pointer(&cursor)), uintptr(unsafe.Pointer(&w)))
+	for _, step := range consoleResetSteps(csbi.cursorPosition, csbi.size.x, lineBreaks) {
+		var written dword
+		procSetConsoleCursorPosition.Call(fd, step.position.packed())
+		procFillConsoleOutputCharacter.Call(fd, uintptr(' '), uintptr(step.length), step.position.packed(), uintptr(unsafe.Pointer(&written)))
 	}
 }
+
+// watchResize measures the console periodically for the rest of the process, there's no signal for resizing
+func watchResize() {
+	go func() {
+		for range time.Tick(time.Second) {
+			measure()
+		}
+	}()
+}
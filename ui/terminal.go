@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// escapeSequences enables escape sequences once, see EnableEscapeSequences
+var escapeSequences = sync.OnceValue(enableEscapeSequences)
+
+// EnableEscapeSequences enables escape sequences for StdOut, if the terminal needs it,
+// like the virtual terminal processing of Windows consoles.
+// Returns false if the terminal doesn't support them, so colors must be disabled.
+func EnableEscapeSequences() bool {
+	return escapeSequences()
+}
+
+// resetSequence returns the escape sequence clearing the current line, or the lines above it,
+// leaving the cursor at the start of the topmost cleared line
+func resetSequence(lineBreaks int) string {
+	clearLine := fmt.Sprintf("%c[2K\r", ESCAPE)
+	if lineBreaks == 0 {
+		return clearLine
+	}
+
+	cursorUp := fmt.Sprintf("%c[%dA", ESCAPE, 1)
+	return strings.Repeat(cursorUp+clearLine, lineBreaks)
+}
+
+type short int16
+
+// coord is a position in the screen buffer of a Windows console
+type coord struct {
+	x short
+	y short
+}
+
+// packed returns the coord as passed by value to the Windows console API, x in the low word
+func (c coord) packed() uintptr {
+	return uintptr(uint16(c.x)) | uintptr(uint16(c.y))<<16
+}
+
+// consoleLineClear is a step of resetting with the legacy Windows console API:
+// The cursor is moved to the position, which is overwritten with spaces for the length.
+type consoleLineClear struct {
+	position coord
+	length   int
+}
+
+// consoleResetSteps returns the same steps as resetSequence for the legacy Windows console API,
+// starting at the cursor in a screen buffer of the width
+func consoleResetSteps(cursor coord, width short, lineBreaks int) []consoleLineClear {
+	if lineBreaks == 0 {
+		return []consoleLineClear{{position: coord{x: 0, y: cursor.y}, length: int(width)}}
+	}
+
+	var steps []consoleLineClear
+	for i := 1; i <= lineBreaks; i++ {
+		y := cursor.y - short(i)
+
+		// The cursor can't move above the screen buffer
+		if y < 0 {
+			break
+		}
+
+		steps = append(steps, consoleLineClear{position: coord{x: 0, y: y}, length: int(width)})
+	}
+
+	return steps
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResetSequence(t *testing.T) {
+	tests := []struct {
+		lineBreaks int
+		want       string
+	}{
+		{0, "\x1b[2K\r"},
+		{1, "\x1b[1A\x1b[2K\r"},
+		{3, "\x1b[1A\x1b[2K\r\x1b[1A\x1b[2K\r\x1b[1A\x1b[2K\r"},
+	}
+
+	for _, tt := range tests {
+		if got := resetSequence(tt.lineBreaks); got != tt.want {
+			t.Errorf("resetSequence(%d) = %q, want %q", tt.lineBreaks, got, tt.want)
+		}
+	}
+}
+
+func TestConsoleResetSteps(t *testing.T) {
+	tests := []struct {
+		name       string
+		cursor     coord
+		lineBreaks int
+		want       []consoleLineClear
+	}{
+		{
+			name:       "current line",
+			cursor:     coord{x: 12, y: 5},
+			lineBreaks: 0,
+			want:       []consoleLineClear{{position: coord{x: 0, y: 5}, length: 80}},
+		},
+		{
+			name:       "lines above",
+			cursor:     coord{x: 0, y: 5},
+			lineBreaks: 2,
+			want: []consoleLineClear{
+				{position: coord{x: 0, y: 4}, length: 80},
+				{position: coord{x: 0, y: 3}, length: 80},
+			},
+		},
+		{
+			name:       "top of the buffer",
+			cursor:     coord{x: 0, y: 1},
+			lineBreaks: 3,
+			want:       []consoleLineClear{{position: coord{x: 0, y: 0}, length: 80}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := consoleResetSteps(tt.cursor, 80, tt.lineBreaks)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("consoleResetSteps() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCoordPacked(t *testing.T) {
+	if got := (coord{x: 3, y: 2}).packed(); got != 0x00020003 {
+		t.Errorf("packed() = %#x, want 0x00020003", got)
+	}
+	if got := (coord{x: -1, y: 0}).packed(); got != 0x0000ffff {
+		t.Errorf("packed() = %#x, want 0x0000ffff", got)
+	}
+}
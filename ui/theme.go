@@ -0,0 +1,230 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jwalton/gchalk"
+)
+
+// Themes are the names of the built-in themes
+var Themes = []string{"default", "ascii"}
+
+// Roles of the colors of a Theme
+const (
+	roleHeader     = "header"
+	rolePending    = "pending"
+	roleClean      = "clean"
+	roleAttention  = "attention"
+	roleNeedsSync  = "needs-sync"
+	roleChanges    = "changes"
+	roleSynced     = "synced"
+	roleSkipped    = "skipped"
+	roleError      = "error"
+	roleRetry      = "retry"
+	roleHookFailed = "hook-failed"
+	roleStashes    = "stashes"
+	roleUpstream   = "upstream"
+	roleChanged    = "changed"
+	rolePrompt     = "prompt"
+	roleHighlight  = "highlight"
+	roleReference  = "reference"
+	roleText       = "text"
+	roleMuted      = "muted"
+)
+
+// defaultColors are the styles of each role, as understood by gchalk
+var defaultColors = map[string][]string{
+	roleHeader:     {"blue"},
+	rolePending:    {"gray"},
+	roleClean:      {"gray"},
+	roleAttention:  {"white", "bold"},
+	roleNeedsSync:  {"yellow", "bold"},
+	roleChanges:    {"white"},
+	roleSynced:     {"green", "bold"},
+	roleSkipped:    {"yellow"},
+	roleError:      {"red"},
+	roleRetry:      {"yellow"},
+	roleHookFailed: {"magenta"},
+	roleStashes:    {"white"},
+	roleUpstream:   {"yellow"},
+	roleChanged:    {"yellow", "bold"},
+	rolePrompt:     {"brightYellow"},
+	roleHighlight:  {"yellow", "bold"},
+	roleReference:  {"yellow"},
+	roleText:       {"white"},
+	roleMuted:      {"gray"},
+}
+
+// Theme defines the colors of the repository states, and the symbols of their counts
+type Theme struct {
+	Incoming    string
+	Outgoing    string
+	Changes     string
+	Unversioned string
+	Clean       string
+
+	// Spinner are the frames of the animation of work in progress
+	Spinner []string
+
+	// Separator is between the columns of tables and the parts of the summary
+	Separator string
+
+	// Ellipsis ends contents truncated to fit the terminal
+	Ellipsis string
+
+	colors map[string]*gchalk.Builder
+}
+
+// theme is used by all renderers
+var theme = mustTheme("default")
+
+// SetTheme sets the theme used by all renderers
+func SetTheme(t *Theme) {
+	theme = t
+}
+
+// CurrentTheme returns the theme used by all renderers
+func CurrentTheme() *Theme {
+	return theme
+}
+
+// NewTheme returns the built-in theme with the name, see Themes
+func NewTheme(name string) (*Theme, error) {
+	t := &Theme{
+		Incoming:    "↓",
+		Outgoing:    "↑",
+		Changes:     "*",
+		Unversioned: "?",
+		Clean:       "-",
+		Spinner:     []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"},
+		Separator:   "│",
+		Ellipsis:    "…",
+		colors:      map[string]*gchalk.Builder{},
+	}
+
+	switch name {
+	case "default":
+	case "ascii":
+		// Like the git prompt shows an upstream being behind or ahead
+		t.Incoming = "<"
+		t.Outgoing = ">"
+		t.Spinner = []string{"|", "/", "-", "\\"}
+		t.Separator = "|"
+		t.Ellipsis = "..."
+	default:
+		return nil, fmt.Errorf("unknown theme '%s'", name)
+	}
+
+	for role, styles := range defaultColors {
+		err := t.SetColor(role, styles...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+func mustTheme(name string) *Theme {
+	t, err := NewTheme(name)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// SetColor changes the styles of a role, e.g. "error" to "brightRed" and "bold".
+// Styles are named colors and modifiers, or hex colors like "#ff8800".
+func (t *Theme) SetColor(role string, styles ...string) error {
+	if _, ok := defaultColors[role]; !ok {
+		return fmt.Errorf("unknown color role '%s', expected one of: %v", role, colorRoles())
+	}
+
+	builder, err := gchalk.WithStyle(styles...)
+	if err != nil {
+		return fmt.Errorf("invalid color of '%s': %w", role, err)
+	}
+
+	t.colors[role] = builder
+	return nil
+}
+
+// SetSymbol changes the symbol of a count: "incoming", "outgoing", "changes", "unversioned" or "clean",
+// the frames of the "spinner", one per character, the "separator" of columns, or the "ellipsis" of truncated contents
+func (t *Theme) SetSymbol(name string, symbol string) error {
+	switch name {
+	case "incoming":
+		t.Incoming = symbol
+	case "outgoing":
+		t.Outgoing = symbol
+	case "changes":
+		t.Changes = symbol
+	case "unversioned":
+		t.Unversioned = symbol
+	case "clean":
+		t.Clean = symbol
+	case "spinner":
+		if len(symbol) == 0 {
+			return fmt.Errorf("spinner needs at least one frame")
+		}
+		t.Spinner = strings.Split(symbol, "")
+	case "separator":
+		t.Separator = symbol
+	case "ellipsis":
+		t.Ellipsis = symbol
+	default:
+		return fmt.Errorf("unknown symbol '%s'", name)
+	}
+	return nil
+}
+
+// paint colors the string with the style of the role
+func (t *Theme) paint(role string, s string) string {
+	return t.colors[role].Paint(s)
+}
+
+// paintf formats and colors the string with the style of the role
+func (t *Theme) paintf(role string, format string, a ...any) string {
+	return t.colors[role].Sprintf(format, a...)
+}
+
+// separator returns the separator of columns, surrounded by spaces
+func (t *Theme) separator() string {
+	return " " + t.Separator + " "
+}
+
+// header colors a table header
+func (t *Theme) header(s string) string {
+	return t.paint(roleHeader, s)
+}
+
+// PromptMarker returns the colored marker in front of a confirmation prompt
+func PromptMarker() string {
+	return theme.paint(roleAttention, ">>>")
+}
+
+// PromptCount colors a count of a confirmation prompt, with the symbol of what's counted, e.g. "2↓"
+func PromptCount(count int, symbol string) string {
+	return theme.paintf(rolePrompt, "%d%s", count, symbol)
+}
+
+// label returns a textual description of a state, but only without colors,
+// as the colors already tell the state otherwise
+func label(text string) string {
+	if gchalk.GetLevel() != gchalk.LevelNone {
+		return ""
+	}
+	return "(" + text + ")"
+}
+
+// colorRoles returns all roles, sorted
+func colorRoles() []string {
+	roles := make([]string, 0, len(defaultColors))
+	for role := range defaultColors {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/benweidig/tortuga/git/gittest"
+	"github.com/benweidig/tortuga/repo"
+
+	"github.com/jwalton/gchalk"
+)
+
+func TestNewTheme(t *testing.T) {
+	ascii, err := NewTheme("ascii")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ascii.Incoming != "<" || ascii.Outgoing != ">" {
+		t.Errorf("ascii symbols = %q %q, want < >", ascii.Incoming, ascii.Outgoing)
+	}
+
+	_, err = NewTheme("unknown")
+	if err == nil {
+		t.Error("unknown theme didn't fail")
+	}
+
+	if err := ascii.SetColor("error", "brightRed", "bold"); err != nil {
+		t.Error(err)
+	}
+	if err := ascii.SetColor("errors", "red"); err == nil {
+		t.Error("unknown role didn't fail")
+	}
+	if err := ascii.SetColor("error", "no-such-color"); err == nil {
+		t.Error("unknown color didn't fail")
+	}
+	if err := ascii.SetSymbol("stashes", "$"); err == nil {
+		t.Error("unknown symbol didn't fail")
+	}
+}
+
+func TestMonochromeLabels(t *testing.T) {
+	level := gchalk.GetLevel()
+	gchalk.SetLevel(gchalk.LevelNone)
+	defer gchalk.SetLevel(level)
+
+	tests := []struct {
+		name         string
+		r            *repo.Repository
+		incomingOnly bool
+		want         string
+	}{
+		{"pending", &repo.Repository{State: repo.StateNone}, false, "... (pending)"},
+		{"up to date", &repo.Repository{State: repo.StateRemoteFetched}, false, "- (up to date)"},
+		{"local changes", &repo.Repository{State: repo.StateRemoteFetched, Changes: 1, Unversioned: 2}, false, "1* 2? (local changes)"},
+		{"needs sync", &repo.Repository{State: repo.StateRemoteFetched, Incoming: 2, Changes: 1}, false, "2↓ 1* (needs sync)"},
+		{"syncing", &repo.Repository{State: repo.StateNeedsSync, Incoming: 2}, false, "... (syncing)"},
+		{"synced", &repo.Repository{State: repo.StateSynced, Incoming: 2, Outgoing: 1}, false, "2↓ 1↑ (synced)"},
+		{"skipped", &repo.Repository{State: repo.StateSynced, Outgoing: 1}, true, "1↑ (skipped)"},
+		{"error", &repo.Repository{State: repo.StateError, Error: errors.New("auth error")}, false, "auth error (error)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := repositoryRow(tt.r, tt.incomingOnly)[statusColumn]; got != tt.want {
+				t.Errorf("status = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Retries are only known while updating
+	t.Run("retrying", func(t *testing.T) {
+		f := gittest.NewFixture(t)
+
+		backoff := repo.RetryBackoff
+		repo.RetryBackoff = time.Millisecond
+		defer func() {
+			repo.RetryBackoff = backoff
+		}()
+
+		r, err := repo.NewRepositoryWithBackend(f.UpToDate("retrying"), gittest.NewFakeBackend().Fail(gittest.OpFetch, gittest.TimeoutError()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.MaxRetries = 2
+
+		var got string
+		r.OnRetry = func() {
+			got = repositoryRow(r, false)[statusColumn]
+		}
+		r.Update()
+
+		if want := "retry 1/2 (retrying)"; got != want {
+			t.Errorf("status = %q, want %q", got, want)
+		}
+	})
+}
+
+func TestDefaultPromptFormat(t *testing.T) {
+	previous := CurrentTheme()
+	defer SetTheme(previous)
+
+	SetTheme(mustTheme("ascii"))
+	if got := DefaultPromptFormat(); got != "{behind}< {ahead}>" {
+		t.Errorf("ascii prompt format = %q, want {behind}< {ahead}>", got)
+	}
+}
@@ -0,0 +1,41 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/benweidig/tortuga/repo"
+)
+
+// WatchRow is the watch state of a single repository
+type WatchRow struct {
+	Updated time.Time
+	Changed bool
+}
+
+// WriteWatchStatus writes the current status with the time of the last update of each
+// repository to the provided Writer. Repositories changed by the last update are highlighted.
+func WriteWatchStatus(w io.Writer, repos []*repo.Repository, rows []WatchRow, interval time.Duration) {
+	columnizer := newColumnizer().Prioritize(statusColumn)
+	columnizer.AddRow(theme.header("REPOSITORY"), theme.header("BRANCH"), theme.header("STATUS"), theme.header("UPDATED"))
+
+	for idx, r := range repos {
+		cells := repositoryRow(r, false)
+
+		row := rows[idx]
+		switch {
+		case row.Updated.IsZero():
+			cells = append(cells, theme.paint(rolePending, "..."))
+		case row.Changed:
+			cells = append(cells, theme.paintf(roleChanged, "%s changed", row.Updated.Format("15:04:05")))
+		default:
+			cells = append(cells, theme.paint(roleMuted, row.Updated.Format("15:04:05")))
+		}
+
+		columnizer.AddRow(cells...)
+	}
+
+	fmt.Fprintln(w, columnizer.FitTo(w))
+	fmt.Fprintln(w, theme.paint(roleMuted, fmt.Sprintf("Fetching every %s, press Ctrl+C to quit", interval)))
+}